@@ -6,14 +6,35 @@ type cliArgs struct {
 	InstallCompletions kongplete.InstallCompletions `cmd:"install-completions" help:"Install completions and exit."`
 	Version            versionCommand               `cmd:"" help:"Show version and exit." short:"v"`
 
-	PkgPath  pkgPath         `help:"Package path to look in." short:"p" default:"." predict:"PKG_PATH"`
+	PkgPath      pkgPath    `help:"Package path to look in." short:"p" default:"." predict:"PKG_PATH"`
+	Instances    []typeArgs `help:"Comma separated type arguments to instantiate generic function or type with. Can be repeated." name:"instance" short:"i" sep:"none" placeholder:"TYPES"`
+	SkipExisting bool       `help:"Leave tests generated before as is. This is the default." xor:"existing"`
+	Update       bool       `help:"Regenerate tests generated before keeping their test cases." xor:"existing"`
+	Force        bool       `help:"Replace tests generated before as a whole." xor:"existing"`
+	NoLoadCache  bool       `help:"Parse and type check sources of all dependencies instead of using export data from the go build cache."`
+	DryRun       bool       `help:"Print generated and changed files instead of writing them." xor:"output"`
+	Diff         bool       `help:"Print unified diff of generated changes instead of writing them." xor:"output"`
+	NoConfig     bool       `help:"Do not read .ttgen.yaml project config."`
+	MockContext  bool       `help:"Require mocks for context.Context parameters and fields."`
+	NoMock       []typePath `help:"Type that does not need to be mocked, as <package path>.<type name>. Can be repeated." placeholder:"TYPE"`
+	MockPath     []string   `help:"Package path to look for mocks in if there is none in the type's package. Can be repeated." placeholder:"PATH"`
+	MockTemplate string     `help:"Mock type name template, e.g. Mock$${type} for mockgen and $${type|P}Mock for pamgen mocks. The project config or built in one is used by default." placeholder:"TEMPLATE"`
+	TestFile     string     `help:"Name of a file in the package directory to put tests into. Tests go to the file paired with the source one by default." placeholder:"FILE"`
+	External     bool       `help:"Generate black-box tests in the external _test package."`
+	CtxCancel    bool       `help:"Add ctxTimeout and cancelBeforeCall fields to test cases to derive the context with." name:"ctx-cancel"`
+	Parallel     bool       `help:"Run tests and their subtests in parallel."`
+
 	Method   commandMethod   `cmd:"" help:"Generate test template for a method."`
 	Function commandFunction `cmd:"" help:"Generate test template for a function."`
+	All      commandPackage  `cmd:"" help:"Generate test templates for all functions and methods of a package."`
+	At       commandAt       `cmd:"" help:"Generate test template for a function or a method declared at the source position."`
+	Serve    commandServe    `cmd:"" help:"Run as a server."`
 }
 
 type runContext struct {
-	args    *cliArgs
-	lookup  MockLookup
-	logging GenLoggingRenderer
-	opts    []GenOption
+	args      *cliArgs
+	lookup    MockLookup
+	logging   GenLoggingRenderer
+	opts      []GenOption
+	overrides []GenOption
 }
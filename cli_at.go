@@ -0,0 +1,63 @@
+package ttgenlib
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/sirkon/errors"
+)
+
+// commandAt command to render test for a function or a method at the source position.
+type commandAt struct {
+	Position sourcePosition `arg:"" help:"Source position as FILE:LINE[:COL]." required:""`
+}
+
+// Run runs command logic.
+func (c commandAt) Run(ctx *runContext) error {
+	return Generate(context.Background(), Request{
+		Targets: []GenTarget{
+			GenTargetAt(c.Position.file, c.Position.line, c.Position.column),
+		},
+		MockLookup: ctx.lookup,
+		Logging:    ctx.logging,
+		Options:    ctx.opts,
+		Overrides:  ctx.overrides,
+		NoConfig:   ctx.args.NoConfig,
+	})
+}
+
+// sourcePosition FILE:LINE[:COL] source position.
+type sourcePosition struct {
+	file   string
+	line   int
+	column int
+}
+
+// UnmarshalText to satisfy encoding.TestUnmarshaler.
+func (p *sourcePosition) UnmarshalText(t []byte) error {
+	// Numbers are taken from the end as file names may have colons.
+	parts := strings.Split(string(t), ":")
+	var nums []int
+	for len(parts) > 1 && len(nums) < 2 {
+		v, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			break
+		}
+
+		nums = append([]int{v}, nums...)
+		parts = parts[:len(parts)-1]
+	}
+
+	file := strings.Join(parts, ":")
+	if file == "" || len(nums) == 0 || nums[0] < 1 {
+		return errors.Newf("'%s' is invalid source position, FILE:LINE[:COL] expected", string(t))
+	}
+
+	p.file = file
+	p.line = nums[0]
+	if len(nums) > 1 {
+		p.column = nums[1]
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package ttgenlib
+
+import (
+	"testing"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/testlog"
+)
+
+func TestSourcePositionUnmarshalText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    sourcePosition
+		wantErr bool
+	}{
+		{
+			name: "line",
+			text: "file.go:12",
+			want: sourcePosition{file: "file.go", line: 12},
+		},
+		{
+			name: "line and column",
+			text: "internal/file.go:12:5",
+			want: sourcePosition{file: "internal/file.go", line: 12, column: 5},
+		},
+		{
+			name: "file with colons",
+			text: `C:\project\file.go:12:5`,
+			want: sourcePosition{file: `C:\project\file.go`, line: 12, column: 5},
+		},
+		{
+			name: "file with numeric colon part",
+			text: "dir:1/file.go:3",
+			want: sourcePosition{file: "dir:1/file.go", line: 3},
+		},
+		{
+			name:    "no line",
+			text:    "file.go",
+			wantErr: true,
+		},
+		{
+			name:    "invalid line",
+			text:    "file.go:line",
+			wantErr: true,
+		},
+		{
+			name:    "zero line",
+			text:    "file.go:0:1",
+			wantErr: true,
+		},
+		{
+			name:    "no file",
+			text:    ":12",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got sourcePosition
+			err := got.UnmarshalText([]byte(tt.text))
+			switch {
+			case err != nil && tt.wantErr:
+				testlog.Log(t, errors.Wrap(err, "expected error"))
+				return
+			case err != nil:
+				testlog.Error(t, errors.Wrap(err, "unmarshal source position"))
+				return
+			case tt.wantErr:
+				t.Errorf("error was expected, got %+v", got)
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("unexpected source position %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
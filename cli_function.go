@@ -1,6 +1,6 @@
 package ttgenlib
 
-import "github.com/sirkon/ttgenlib/internal/generator"
+import "context"
 
 // commandFunction command to render function test.
 type commandFunction struct {
@@ -9,11 +9,17 @@ type commandFunction struct {
 
 // Run runs command logic.
 func (c commandFunction) Run(ctx *runContext) error {
-	return generator.GenerateForFunction(
-		ctx.args.PkgPath.String(),
-		ctx.args.Function.Name.String(),
-		ctx.lookup,
-		ctx.logging,
-		ctx.opts...,
-	)
+	return Generate(context.Background(), Request{
+		Package: ctx.args.PkgPath.String(),
+		Targets: []GenTarget{
+			{
+				Name: ctx.args.Function.Name.String(),
+			},
+		},
+		MockLookup: ctx.lookup,
+		Logging:    ctx.logging,
+		Options:    ctx.opts,
+		Overrides:  ctx.overrides,
+		NoConfig:   ctx.args.NoConfig,
+	})
 }
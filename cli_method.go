@@ -1,6 +1,6 @@
 package ttgenlib
 
-import "github.com/sirkon/ttgenlib/internal/generator"
+import "context"
 
 // commandMethod command to render type's method test.
 type commandMethod struct {
@@ -10,12 +10,18 @@ type commandMethod struct {
 
 // Run runs command logic.
 func (c commandMethod) Run(ctx *runContext) error {
-	return generator.GenerateForMethod(
-		ctx.args.PkgPath.String(),
-		ctx.args.Method.Type.String(),
-		ctx.args.Method.Name.String(),
-		ctx.lookup,
-		ctx.logging,
-		ctx.opts...,
-	)
+	return Generate(context.Background(), Request{
+		Package: ctx.args.PkgPath.String(),
+		Targets: []GenTarget{
+			{
+				Type: ctx.args.Method.Type.String(),
+				Name: ctx.args.Method.Name.String(),
+			},
+		},
+		MockLookup: ctx.lookup,
+		Logging:    ctx.logging,
+		Options:    ctx.opts,
+		Overrides:  ctx.overrides,
+		NoConfig:   ctx.args.NoConfig,
+	})
 }
@@ -0,0 +1,47 @@
+package ttgenlib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirkon/ttgenlib/internal/udiff"
+)
+
+// printFiles prints generated and changed files into the stdout.
+func printFiles(files []GenRenderedFile) error {
+	for i, file := range files {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("// %s\n", relPath(file.Path))
+		fmt.Print(string(file.Content))
+	}
+
+	return nil
+}
+
+// printDiff prints unified diff of generated changes into the stdout.
+func printDiff(files []GenRenderedFile) error {
+	for _, file := range files {
+		name := filepath.ToSlash(relPath(file.Path))
+		fmt.Print(udiff.Unified("a/"+name, "b/"+name, file.Old, file.Content))
+	}
+
+	return nil
+}
+
+// relPath returns the path relative to the current directory if possible.
+func relPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	rel, err := filepath.Rel(wd, path)
+	if err != nil {
+		return path
+	}
+
+	return rel
+}
@@ -0,0 +1,50 @@
+package ttgenlib
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/sirkon/errors"
+)
+
+// commandPackage command to render tests for all functions and methods of a package.
+type commandPackage struct {
+	Include      string `help:"Only generate tests for functions and methods (as Type.Method) matching this regexp." short:"I"`
+	Exclude      string `help:"Do not generate tests for functions and methods (as Type.Method) matching this regexp." short:"E"`
+	ExportedOnly bool   `help:"Only generate tests for exported functions and methods." short:"x"`
+}
+
+// Run runs command logic.
+func (c commandPackage) Run(ctx *runContext) error {
+	var filter GenPackageFilter
+	filter.ExportedOnly = c.ExportedOnly
+
+	if c.Include != "" {
+		include, err := regexp.Compile(c.Include)
+		if err != nil {
+			return errors.Wrap(err, "compile include regexp")
+		}
+
+		filter.Include = include
+	}
+
+	if c.Exclude != "" {
+		exclude, err := regexp.Compile(c.Exclude)
+		if err != nil {
+			return errors.Wrap(err, "compile exclude regexp")
+		}
+
+		filter.Exclude = exclude
+	}
+
+	return Generate(context.Background(), Request{
+		Package:    ctx.args.PkgPath.String(),
+		All:        true,
+		Filter:     filter,
+		MockLookup: ctx.lookup,
+		Logging:    ctx.logging,
+		Options:    ctx.opts,
+		Overrides:  ctx.overrides,
+		NoConfig:   ctx.args.NoConfig,
+	})
+}
@@ -0,0 +1,56 @@
+package ttgenlib
+
+import (
+	"context"
+	"os"
+
+	"github.com/sirkon/ttgenlib/internal/lsp"
+)
+
+// commandServe command to run a language server.
+type commandServe struct {
+	LSP bool `help:"Serve language server protocol on stdio." name:"lsp" required:""`
+}
+
+// Run runs command logic.
+func (c commandServe) Run(ctx *runContext) error {
+	cache := NewGenCache()
+
+	return lsp.Serve(context.Background(), os.Stdin, os.Stdout, lsp.Config{
+		Name: globalVarAppName,
+		Generate: func(
+			genctx context.Context,
+			file string,
+			line, column int,
+			overlay map[string][]byte,
+		) ([]GenRenderedFile, error) {
+			var res []GenRenderedFile
+			overrides := append(
+				ctx.overrides[:len(ctx.overrides):len(ctx.overrides)],
+				GenWithCache(cache),
+				GenOverlay(overlay),
+				GenDryRun(func(files []GenRenderedFile) error {
+					res = files
+					return nil
+				}),
+			)
+
+			err := Generate(genctx, Request{
+				Targets: []GenTarget{
+					GenTargetAt(file, line, column),
+				},
+				MockLookup: ctx.lookup,
+				Logging:    ctx.logging,
+				Options:    ctx.opts,
+				Overrides:  overrides,
+				NoConfig:   ctx.args.NoConfig,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			return res, nil
+		},
+		Changed: cache.Invalidate,
+	})
+}
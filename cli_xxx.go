@@ -2,9 +2,9 @@ package ttgenlib
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/sirkon/errors"
-	"github.com/sirkon/jsonexec"
 )
 
 // goIdentifier to check correctness of text that should represent Go identifiers.
@@ -31,17 +31,67 @@ func (p pkgPath) String() string {
 	return string(p)
 }
 
-// UnmarshalText to satisfy encoding.TestUnmarshaler.
+// UnmarshalText to satisfy encoding.TestUnmarshaler. The package itself is
+// checked when it is loaded for the generation.
 func (p *pkgPath) UnmarshalText(t []byte) error {
-	var dst struct {
-		ImportPath string
+	if len(t) == 0 {
+		return errors.New("package path must not be empty")
+	}
+
+	*p = pkgPath(t)
+	return nil
+}
+
+// typePath a type given as <package path>.<type name>.
+type typePath struct {
+	pkg  string
+	name string
+}
+
+// UnmarshalText to satisfy encoding.TestUnmarshaler.
+func (p *typePath) UnmarshalText(t []byte) error {
+	pkg, name := splitTypePath(string(t))
+	if pkg == "" || !goIdentifierMatcher.MatchString(name) {
+		return errors.Newf("'%s' is invalid type, must be <package path>.<type name>", string(t))
+	}
+
+	p.pkg = pkg
+	p.name = name
+	return nil
+}
+
+// typeArgs a list of type arguments for generic instantiation.
+type typeArgs []string
+
+// UnmarshalText to satisfy encoding.TestUnmarshaler.
+func (a *typeArgs) UnmarshalText(t []byte) error {
+	var res typeArgs
+	var depth int
+	var start int
+	for i, c := range t {
+		switch c {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth > 0 {
+				continue
+			}
+
+			res = append(res, strings.TrimSpace(string(t[start:i])))
+			start = i + 1
+		}
 	}
+	res = append(res, strings.TrimSpace(string(t[start:])))
 
-	if err := jsonexec.Run(&dst, "go", "list", "--json", string(t)); err != nil {
-		return err
+	for _, arg := range res {
+		if arg == "" {
+			return errors.Newf("'%s' is invalid type arguments list", string(t))
+		}
 	}
 
-	*p = pkgPath(dst.ImportPath)
+	*a = res
 	return nil
 }
 
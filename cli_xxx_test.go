@@ -0,0 +1,81 @@
+package ttgenlib
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/testlog"
+)
+
+func TestTypeArgsUnmarshalText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    typeArgs
+		wantErr bool
+	}{
+		{
+			name: "single",
+			text: "int",
+			want: typeArgs{"int"},
+		},
+		{
+			name: "several with spaces",
+			text: " string , int ",
+			want: typeArgs{"string", "int"},
+		},
+		{
+			name: "nested brackets",
+			text: "map[string][]int,Pair[int, Pair[string, bool]]",
+			want: typeArgs{"map[string][]int", "Pair[int, Pair[string, bool]]"},
+		},
+		{
+			name: "func types",
+			text: "func(int, string) (bool, error),int",
+			want: typeArgs{"func(int, string) (bool, error)", "int"},
+		},
+		{
+			name: "struct types",
+			text: "struct{a, b int},chan int",
+			want: typeArgs{"struct{a, b int}", "chan int"},
+		},
+		{
+			name:    "empty",
+			text:    "",
+			wantErr: true,
+		},
+		{
+			name:    "empty element",
+			text:    "int,,string",
+			wantErr: true,
+		},
+		{
+			name:    "trailing comma",
+			text:    "int, ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got typeArgs
+			err := got.UnmarshalText([]byte(tt.text))
+			switch {
+			case err != nil && tt.wantErr:
+				testlog.Log(t, errors.Wrap(err, "expected error"))
+				return
+			case err != nil:
+				testlog.Error(t, errors.Wrap(err, "unmarshal type arguments"))
+				return
+			case tt.wantErr:
+				t.Errorf("error was expected, got %q", got)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected type arguments %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package ttgenlib
+
+import (
+	"go/build"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/message"
+	"github.com/sirkon/ttgenlib/internal/generator"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is a name of the project configuration file. It is looked for
+// in the directory of the package tests are generated for and then up to the root.
+const ConfigFileName = ".ttgen.yaml"
+
+// projectConfig a project configuration of the generation:
+//
+//	mock:
+//	  paths: [internal/mocks]        # alternative mock paths
+//	  template: ${type}Mock          # mock type name template
+//	  custom:                        # mock type names for certain types
+//	    github.com/org/app/internal/storage.Repo: RepoMock
+//	  context: true                  # mock context.Context
+//	no_mock:                         # types that don't need to be mocked
+//	  - io.Writer
+//	mocker:
+//	  file: ${type|_}_mocks_test.go  # mocker file name template
+//	  type: ${type|p}Mocks           # mocker type name template
+type projectConfig struct {
+	Mock struct {
+		Paths    []string          `yaml:"paths"`
+		Template string            `yaml:"template"`
+		Custom   map[string]string `yaml:"custom"`
+		Context  bool              `yaml:"context"`
+	} `yaml:"mock"`
+	NoMock []string `yaml:"no_mock"`
+	Mocker struct {
+		File string `yaml:"file"`
+		Type string `yaml:"type"`
+	} `yaml:"mocker"`
+}
+
+// findConfig looks for the project config file starting from the directory of the package.
+// Returns nil if there is none.
+func findConfig(pkg string) (*projectConfig, error) {
+	dir := pkg
+	if !build.IsLocalImport(pkg) && !filepath.IsAbs(pkg) {
+		// This is an import path, the current directory is within the project then.
+		dir = "."
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "get absolute path of the package directory")
+	}
+
+	for {
+		name := filepath.Join(dir, ConfigFileName)
+		data, err := os.ReadFile(name)
+		switch {
+		case err == nil:
+			message.Debugf("using config %s", name)
+			return readConfig(name, data)
+		case !os.IsNotExist(err):
+			return nil, errors.Wrapf(err, "read %s", name)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+func readConfig(name string, data []byte) (*projectConfig, error) {
+	var cfg projectConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "parse %s", name)
+	}
+
+	for _, nm := range cfg.NoMock {
+		if pkg, typ := splitTypePath(nm); pkg == "" || typ == "" {
+			return nil, errors.Newf("%s: invalid no_mock type %q, must be <package path>.<type name>", name, nm)
+		}
+	}
+
+	return &cfg, nil
+}
+
+// lookup returns the mock lookup with configured mocks, nil if there are none.
+func (c *projectConfig) lookup() MockLookup {
+	if c.Mock.Template == "" && len(c.Mock.Custom) == 0 {
+		return nil
+	}
+
+	return func(p generator.PackageProvider, t *types.Named) (MockLookupResult, error) {
+		// Custom names are keyed with <package path>.<type name> in the config.
+		name, ok := c.Mock.Custom[t.Obj().Pkg().Path()+"."+t.Obj().Name()]
+		if !ok && c.Mock.Template == "" {
+			// Names of other mocks are up to lookups given in code.
+			return MockLookupResult{}, generator.ErrorMockNotFound
+		}
+
+		var custom map[string]string
+		if ok {
+			custom = map[string]string{
+				t.Obj().String(): name,
+			}
+		}
+
+		return StandardMockLookup(nil, c.Mock.Template, custom)(p, t)
+	}
+}
+
+// options returns generation options the config sets.
+func (c *projectConfig) options() []GenOption {
+	var res []GenOption
+	if lookup := c.lookup(); lookup != nil {
+		res = append(res, GenMockLookup(lookup))
+	}
+	if len(c.Mock.Paths) > 0 {
+		res = append(res, GenMockPaths(c.Mock.Paths...))
+	}
+	if c.Mock.Context {
+		res = append(res, GenMockContext())
+	}
+	for _, nm := range c.NoMock {
+		pkg, name := splitTypePath(nm)
+		res = append(res, GenNoMock(pkg, name))
+	}
+	if c.Mocker.File != "" || c.Mocker.Type != "" {
+		res = append(res, generator.WithMockerTemplates(c.Mocker.File, c.Mocker.Type))
+	}
+
+	return res
+}
+
+// splitTypePath splits <package path>.<type name> into the package path and the type name.
+func splitTypePath(v string) (pkg string, name string) {
+	pos := strings.LastIndexByte(v, '.')
+	if pos < 0 {
+		return "", v
+	}
+
+	return v[:pos], v[pos+1:]
+}
@@ -0,0 +1,208 @@
+package ttgenlib
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/testlog"
+	"github.com/sirkon/ttgenlib/internal/generator"
+)
+
+func TestReadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		options int
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			data:    "",
+			options: 0,
+		},
+		{
+			name: "full",
+			data: `
+mock:
+  paths: [internal/mocks]
+  template: ${type}Mock
+  custom:
+    github.com/org/app/internal/storage.Repo: RepoMock
+  context: true
+no_mock:
+  - io.Writer
+  - github.com/org/app/internal/storage.Cache
+mocker:
+  file: ${type|_}_mocks_test.go
+`,
+			// Lookup, mock paths, context, two no mocks and mocker templates.
+			options: 6,
+		},
+		{
+			name: "template only",
+			data: `
+mock:
+  template: ${type}Mock
+`,
+			options: 1,
+		},
+		{
+			name: "paths only",
+			data: `
+mock:
+  paths: [internal/mocks]
+`,
+			options: 1,
+		},
+		{
+			name: "no_mock without package",
+			data: `
+no_mock:
+  - Writer
+`,
+			wantErr: true,
+		},
+		{
+			name: "no_mock without type",
+			data: `
+no_mock:
+  - io.
+`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid yaml",
+			data:    "mock: [",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := readConfig(ConfigFileName, []byte(tt.data))
+			switch {
+			case err != nil && tt.wantErr:
+				testlog.Log(t, errors.Wrap(err, "expected error"))
+				return
+			case err != nil:
+				testlog.Error(t, errors.Wrap(err, "read config"))
+				return
+			case tt.wantErr:
+				t.Error("error was expected")
+				return
+			}
+
+			if opts := cfg.options(); len(opts) != tt.options {
+				t.Errorf("unexpected number of options %d, want %d", len(opts), tt.options)
+			}
+		})
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	root := t.TempDir()
+	pkg := filepath.Join(root, "internal", "app")
+	if err := os.MkdirAll(pkg, 0755); err != nil {
+		testlog.Error(t, errors.Wrap(err, "create package directory"))
+		return
+	}
+
+	cfg, err := findConfig(pkg)
+	if err != nil {
+		testlog.Error(t, errors.Wrap(err, "look for missing config"))
+		return
+	}
+	if cfg != nil {
+		t.Errorf("no config expected, got %+v", cfg)
+	}
+
+	data := []byte("no_mock:\n  - io.Writer\n")
+	if err := os.WriteFile(filepath.Join(root, ConfigFileName), data, 0600); err != nil {
+		testlog.Error(t, errors.Wrap(err, "write config"))
+		return
+	}
+
+	cfg, err = findConfig(pkg)
+	if err != nil {
+		testlog.Error(t, errors.Wrap(err, "look for config"))
+		return
+	}
+	if cfg == nil {
+		t.Error("config in the parent directory was not found")
+		return
+	}
+	if !reflect.DeepEqual(cfg.NoMock, []string{"io.Writer"}) {
+		t.Errorf("unexpected no_mock %q", cfg.NoMock)
+	}
+}
+
+func TestRequestOptionsPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte("mock:\n  context: true\nmocker:\n  type: ${type}Mocks\n")
+	if err := os.WriteFile(filepath.Join(dir, ConfigFileName), data, 0600); err != nil {
+		testlog.Error(t, errors.Wrap(err, "write config"))
+		return
+	}
+
+	ctx := context.Background()
+	req := Request{
+		Options:   []GenOption{GenTestFile("options_test.go")},
+		Overrides: []GenOption{GenExternalTests()},
+	}
+
+	tests := []struct {
+		name     string
+		noConfig bool
+		want     []GenOption
+	}{
+		{
+			name: "config between options and overrides",
+			want: []GenOption{
+				generator.WithContext(ctx),
+				GenTestFile("options_test.go"),
+				GenMockContext(),
+				generator.WithMockerTemplates("", ""),
+				GenExternalTests(),
+			},
+		},
+		{
+			name:     "no config",
+			noConfig: true,
+			want: []GenOption{
+				generator.WithContext(ctx),
+				GenTestFile("options_test.go"),
+				GenExternalTests(),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := req
+			req.NoConfig = tt.noConfig
+			opts, err := requestOptions(ctx, dir, req)
+			if err != nil {
+				testlog.Error(t, errors.Wrap(err, "get request options"))
+				return
+			}
+
+			if got, want := optionFuncs(opts), optionFuncs(tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("unexpected options order %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// optionFuncs identifies options by functions that made them.
+func optionFuncs(opts []GenOption) []uintptr {
+	res := make([]uintptr, len(opts))
+	for i, opt := range opts {
+		res[i] = reflect.ValueOf(opt).Pointer()
+	}
+
+	return res
+}
@@ -0,0 +1,104 @@
+package ttgenlib
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/ttgenlib/internal/generator"
+)
+
+// Request describes what to generate tests for and how.
+type Request struct {
+	// Package is a path of the package to look in. It is the package of
+	// the first target's file if targets are given by source positions
+	// and the package in the current directory otherwise.
+	Package string
+
+	// Targets are functions and methods to generate tests for.
+	Targets []GenTarget
+
+	// All generates tests for all functions and methods of the package passing
+	// the Filter instead of Targets.
+	All    bool
+	Filter GenPackageFilter
+
+	// MockLookup looks for mocks of interfaces. StandardMockLookup for mockgen
+	// mocks in types' own packages is used if nil.
+	MockLookup MockLookup
+
+	// Logging renders error messages in tests.
+	Logging GenLoggingRenderer
+
+	// Options of the generation. Settings of the project config file take precedence
+	// over them.
+	Options []GenOption
+
+	// Overrides are options applied after the project config file, like ones
+	// derived from command line flags.
+	Overrides []GenOption
+
+	// NoConfig disables the project config file lookup, see ConfigFileName.
+	NoConfig bool
+}
+
+// Generate generates tests described by the request. It neither reads command line
+// arguments nor exits the process, so it can be used from other tools and tests.
+// The context is used to load packages and to stop the generation.
+func Generate(ctx context.Context, req Request) error {
+	if req.Logging == nil {
+		return errors.New("logging renderer is required")
+	}
+
+	if !req.All && len(req.Targets) == 0 {
+		return errors.New("no functions or methods to generate tests for")
+	}
+
+	pkg := req.Package
+	switch {
+	case pkg != "":
+	case len(req.Targets) > 0 && req.Targets[0].File != "":
+		dir, err := filepath.Abs(filepath.Dir(req.Targets[0].File))
+		if err != nil {
+			return errors.Wrap(err, "get package directory of the target file")
+		}
+
+		pkg = dir
+	default:
+		pkg = "."
+	}
+
+	lookup := req.MockLookup
+	if lookup == nil {
+		lookup = StandardMockLookup(nil, "Mock${type}", nil)
+	}
+
+	opts, err := requestOptions(ctx, pkg, req)
+	if err != nil {
+		return err
+	}
+
+	if req.All {
+		return generator.GenerateForPackage(pkg, req.Filter, lookup, req.Logging, opts...)
+	}
+
+	return generator.Generate(pkg, req.Targets, lookup, req.Logging, opts...)
+}
+
+// requestOptions returns generation options of the request in the order they are
+// applied: request options, then project config settings, then overrides.
+func requestOptions(ctx context.Context, pkg string, req Request) ([]GenOption, error) {
+	opts := append([]GenOption{generator.WithContext(ctx)}, req.Options...)
+	if !req.NoConfig {
+		cfg, err := findConfig(pkg)
+		if err != nil {
+			return nil, errors.Wrap(err, "look for project config")
+		}
+
+		if cfg != nil {
+			opts = append(opts, cfg.options()...)
+		}
+	}
+
+	return append(opts, req.Overrides...), nil
+}
@@ -22,6 +22,93 @@ func GenMockContext() GenOption {
 	return generator.WithMockContext
 }
 
+// GenMockBackend sets a mocking library backend. It is GomockBackend by default.
+// Remember to set a mock name template for the StandardMockLookup accordingly,
+// "Mock${type}" for mockery and "${type}Mock" for moq and minimock.
+func GenMockBackend(backend MockBackend) GenOption {
+	return generator.WithMockBackend(backend)
+}
+
+// GenMockLookup adds a mock lookup tried before the one generation was given. The latter
+// is used if the former returns no mock, lookups added later are tried first.
+func GenMockLookup(lookup MockLookup) GenOption {
+	return generator.WithMockLookup(lookup)
+}
+
+// GenMockPaths adds packages to look for mocks in when the mock lookup finds none for
+// a type. The lookup looks in them the way it looks in the type's own package, so mock
+// names follow its template. Paths can be relative to the module root.
+func GenMockPaths(paths ...string) GenOption {
+	return generator.WithMockPaths(paths...)
+}
+
+// GenTestFile puts generated tests into the file with the given name in the package
+// directory. They go to the _test.go file paired with the source file by default.
+func GenTestFile(name string) GenOption {
+	return generator.WithTestFile(name)
+}
+
+// GenExternalTests generates black-box tests in the external _test package. Only exported
+// functions and methods can be tested this way. Receivers are declared for the user to
+// set up, their fields are not mocked. Tests are put into _external_test.go files paired
+// with source files unless GenTestFile is used.
+func GenExternalTests() GenOption {
+	return generator.WithExternalTests
+}
+
+// GenMissingMocks enables generation of gomock mocks for interfaces no mock was
+// found for. Mocks are put into the package at path relative to the module root,
+// or next to tests in _mock_test.go files if path is empty. Mocks of interfaces of
+// the package under test always go next to tests, that package would import it and
+// cause an import cycle otherwise. The template names mock types the way
+// StdMockLookup does, "Mock${type}" if empty. Mocks generated before are reused
+// rather than rendered again. The package name is taken from the path, without
+// a major version suffix, if the package does not exist yet.
+func GenMissingMocks(path, template string) GenOption {
+	return generator.WithMockGeneration(path, template)
+}
+
+// GenRenderedFile a file created or changed by the generation.
+type GenRenderedFile = generator.RenderedFile
+
+// GenOverlay sets contents of files that differ from ones on disk, like unsaved
+// editor buffers. Keys are absolute file paths.
+func GenOverlay(overlay map[string][]byte) GenOption {
+	return generator.WithOverlay(overlay)
+}
+
+// GenDryRun makes the generation pass files it created or changed to the handler
+// instead of writing them.
+func GenDryRun(handler func(files []GenRenderedFile) error) GenOption {
+	return generator.WithDryRun(handler)
+}
+
+// GenMissingMockPolicy defines what to do with parameters and fields no mock was found for.
+type GenMissingMockPolicy = generator.MissingMockPolicy
+
+const (
+	// GenMissingMockFail fails the generation. This is the default.
+	GenMissingMockFail = generator.MissingMockFail
+	// GenMissingMockValue makes the interface a plain field of the test structure,
+	// so an implementation is supplied with each test case.
+	GenMissingMockValue = generator.MissingMockValue
+	// GenMissingMockTODO leaves a TODO placeholder instead of the mock.
+	GenMissingMockTODO = generator.MissingMockTODO
+)
+
+// GenOnMissingMock sets a policy for parameters and fields no mock was found for.
+// What was left without mocks is reported at the end of the generation.
+func GenOnMissingMock(policy GenMissingMockPolicy) GenOption {
+	return generator.WithMissingMockPolicy(policy)
+}
+
+// GenEmbeddedStructs enables mocks for interface fields of structures embedded
+// into the receiver type. Embedded interfaces are always mocked, named after
+// their types.
+func GenEmbeddedStructs() GenOption {
+	return generator.WithEmbeddedStructs
+}
+
 // GenMockerNames this option is used to override default mocker file and type name
 // generation. It is <type_name>_mocker_test.go and <typeName>Mocker by default.
 func GenMockerNames(
@@ -30,6 +117,37 @@ func GenMockerNames(
 	return generator.WithMockerNames(f)
 }
 
+// GenInstance adds type arguments to instantiate generic functions and
+// generic receiver types with. Can be given several times, a test is
+// generated for each instantiation then:
+//
+//	ttgenlib.GenInstance("int", "string")
+//	ttgenlib.GenInstance("[]byte", "time.Duration")
+//
+// Types from other packages can be referred to by the names they are
+// imported with in the file where the function or type is defined.
+func GenInstance(typeArgs ...string) GenOption {
+	return generator.WithInstance(typeArgs...)
+}
+
+// GenExistingPolicy defines how to deal with tests that were generated before.
+type GenExistingPolicy = generator.ExistingPolicy
+
+const (
+	// GenExistingSkip leaves existing tests as is. This is the default.
+	GenExistingSkip = generator.ExistingSkip
+	// GenExistingUpdate regenerates existing tests keeping their test cases and
+	// statements added by hand.
+	GenExistingUpdate = generator.ExistingUpdate
+	// GenExistingForce replaces existing tests as a whole along with their test cases.
+	GenExistingForce = generator.ExistingForce
+)
+
+// GenExisting sets a policy for tests that exist already.
+func GenExisting(policy GenExistingPolicy) GenOption {
+	return generator.WithExisting(policy)
+}
+
 // GenUserDefinedCodeRenderer just a shortcut for hard to read function type defintion.
 type GenUserDefinedCodeRenderer = func(r *gogh.GoRenderer[*gogh.Imports])
 
@@ -39,11 +157,158 @@ func GenPreTest(pretest GenUserDefinedCodeRenderer) GenOption {
 	return generator.WithPreTest(pretest)
 }
 
-// GenCtxInit overrides default context.Context initialization code rendering.
+// GenCtxInit overrides default context.Context initialization code rendering. It is
+// ctx := t.Context() for modules with go 1.24 or later and ctx := context.Background()
+// for older ones.
 func GenCtxInit(ctxinit GenUserDefinedCodeRenderer) GenOption {
 	return generator.WithCtxInit(ctxinit)
 }
 
+// GenContextParam describes a context.Context parameter of the function under test.
+type GenContextParam = generator.ContextParam
+
+// GenCtxParam sets a renderer of contexts passed as context.Context parameters. It is called
+// for each of them and returns an expression to pass, so functions taking several contexts
+// can get distinct ones:
+//
+//	ttgenlib.GenCtxParam(func(r *gogh.GoRenderer[*gogh.Imports], p ttgenlib.GenContextParam) string {
+//		if p.Nth == 0 {
+//			return "ctx"
+//		}
+//
+//		r.Imports().Add("context").Ref("ctxpkg")
+//		r.L(`parentCtx, cancel := $ctxpkg.WithCancel(ctx)`)
+//		r.L(`defer cancel()`)
+//		return "parentCtx"
+//	})
+//
+// ctx is available at the moment. All parameters are given ctx by default.
+func GenCtxParam(ctxparam func(r *gogh.GoRenderer[*gogh.Imports], p GenContextParam) string) GenOption {
+	return generator.WithCtxParam(ctxparam)
+}
+
+// GenCtxSetup adds ctxSetup func(ctx context.Context) context.Context field to test cases.
+// The test context is replaced with what it returns when it is set, this is the place to
+// add values, deadlines or cancellation for a case.
+func GenCtxSetup() GenOption {
+	return generator.WithCtxSetup
+}
+
+// GenCtxCancellation adds ctxTimeout time.Duration and cancelBeforeCall bool fields to
+// test cases, so cancellation and deadline paths can be table driven. The test context
+// gets the timeout when it is set and is cancelled before the call when the flag is.
+func GenCtxCancellation() GenOption {
+	return generator.WithCtxCancellation
+}
+
+// GenParallel makes generated tests and their subtests call t.Parallel(). Mock controllers,
+// mockers and argument mocks are created for each subtest anyway. Package level variables
+// in existing mocker files are reported as they are shared then.
+func GenParallel() GenOption {
+	return generator.WithParallel
+}
+
+// GenCtxInitInfo describes where the test context is initialized: the name of the testing
+// variable, the kind of the testing function and context parameters and receiver fields
+// filled with it.
+type GenCtxInitInfo = generator.ContextInit
+
+// GenTestKind a kind of the testing function.
+type GenTestKind = generator.TestKind
+
+const (
+	// GenTestKindTest is for TestXxx(t *testing.T) functions.
+	GenTestKindTest = generator.TestKindTest
+)
+
+// GenContextInit overrides default context.Context initialization code rendering like
+// GenCtxInit does and gives the renderer details of the place:
+//
+//	ttgenlib.GenContextInit(func(r *gogh.GoRenderer[*gogh.Imports], c ttgenlib.GenCtxInitInfo) {
+//		r.Imports().Add("context").Ref("ctx")
+//		r.Imports().Add("time").Ref("time")
+//		r.L(`ctx, cancel := $ctx.WithTimeout($0.Context(), $time.Second)`, c.Test)
+//		r.L(`defer cancel()`)
+//	})
+func GenContextInit(ctxinit func(r *gogh.GoRenderer[*gogh.Imports], c GenCtxInitInfo)) GenOption {
+	return generator.WithContextInit(ctxinit)
+}
+
+// GenPackageFilter selects functions and methods to generate tests for
+// with GenerateForPackage.
+type GenPackageFilter = generator.PackageFilter
+
+// GenerateForPackage generates table tests for all functions and methods of defined
+// types of the package pkg passing the filter. Everything is loaded and rendered once.
+// Generic functions and methods are skipped unless instances are given.
+func GenerateForPackage(
+	pkg string,
+	filter GenPackageFilter,
+	mockLookup MockLookup,
+	logging GenLoggingRenderer,
+	genOpts ...GenOption,
+) error {
+	return generator.GenerateForPackage(pkg, filter, mockLookup, logging, genOpts...)
+}
+
+// GenCache keeps loaded packages between generations for long living processes.
+// It is in memory only, there is no on-disk cache of its own: single runs rely on
+// export data of dependencies the go build cache keeps.
+type GenCache = generator.Cache
+
+// NewGenCache creates an empty packages cache. Call its Invalidate method when
+// a file is changed: loads having the package of the file among their packages
+// or dependencies are done again.
+func NewGenCache() *GenCache {
+	return generator.NewCache()
+}
+
+// GenWithCache makes the generation reuse packages loaded before with the cache.
+func GenWithCache(cache *GenCache) GenOption {
+	return generator.WithCache(cache)
+}
+
+// GenPreload adds packages to load in one go with the one tests are generated for.
+// Paths can be relative to the module root. Alternative mock paths given to
+// StandardMockLookup are loaded together on the first lookup anyway, pass them
+// here to have them loaded with the package itself.
+func GenPreload(paths ...string) GenOption {
+	return generator.WithPreload(paths...)
+}
+
+// GenSourceLoad disables loading of dependencies from export data kept in the go
+// build cache: their sources are parsed and type checked instead. This is slower.
+func GenSourceLoad() GenOption {
+	return generator.WithSourceLoad
+}
+
+// GenTarget a function or a method to generate a test for. Type is empty for functions.
+// It can also be given by a source position, see GenTargetAt.
+type GenTarget = generator.Target
+
+// GenTargetAt a function or a method whose declaration encloses the given position.
+// Lines and columns start from 1, the column can be 0 to match the whole line.
+func GenTargetAt(file string, line, column int) GenTarget {
+	return GenTarget{
+		File:   file,
+		Line:   line,
+		Column: column,
+	}
+}
+
+// GenerateFiles generates table tests for the targets of the package pkg and returns
+// files created or changed as data. Nothing is written into the module: files are
+// rendered into its scratch copy, which is removed afterwards.
+func GenerateFiles(
+	pkg string,
+	targets []GenTarget,
+	mockLookup MockLookup,
+	logging GenLoggingRenderer,
+	genOpts ...GenOption,
+) ([]GenRenderedFile, error) {
+	return generator.GenerateFiles(pkg, targets, mockLookup, logging, genOpts...)
+}
+
 // GenLoggingRenderer renders error messages.
 // These variables:
 //
@@ -16,7 +16,9 @@ require (
 	github.com/sirkon/message v1.6.1
 	github.com/sirkon/testlog v0.1.0
 	github.com/willabides/kongplete v0.3.0
+	golang.org/x/mod v0.26.0
 	golang.org/x/tools v0.35.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -32,7 +34,6 @@ require (
 	github.com/sirkon/protoast v0.29.0 // indirect
 	github.com/spaolacci/murmur3 v1.1.0 // indirect
 	golang.org/x/exp v0.0.0-20230510235704-dd950f8aeaea // indirect
-	golang.org/x/mod v0.26.0 // indirect
 	golang.org/x/sys v0.34.0 // indirect
 	google.golang.org/protobuf v1.27.1 // indirect
 )
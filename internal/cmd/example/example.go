@@ -15,6 +15,7 @@ func main() {
 		"example",
 		ttgenlib.StandardMockLookup([]string{"internal/extmocks"}, "${type|P}Mock", nil),
 		lr,
+		ttgenlib.GenPreload("internal/extmocks"),
 		ttgenlib.GenPreTest(func(r *gogh.GoRenderer[*gogh.Imports]) {
 			lr.z = r.Z()
 		}),
@@ -10,10 +10,17 @@ var (
 )
 
 const (
-	gomockPath       = "github.com/golang/mock/gomock"
+	gomockModule     = "github.com/golang/mock"
+	gomockPath       = gomockModule + "/gomock"
+	uberGomockModule = "go.uber.org/mock"
+	uberGomockPath   = uberGomockModule + "/gomock"
 	gomockController = "Controller"
+	minimockPath     = "github.com/gojuno/minimock/v3"
+	minimockTester   = "Tester"
 	deepequalPath    = "github.com/sirkon/deepequal"
 
-	PackageLoadMode = packages.NeedImports | packages.NeedTypes | packages.NeedName | packages.NeedDeps |
-		packages.NeedSyntax | packages.NeedFiles | packages.NeedModule | packages.NeedSyntax
+	// PackageLoadMode loads dependencies from export data kept in the go build cache,
+	// sources are only parsed and type checked for requested packages.
+	PackageLoadMode = packages.NeedImports | packages.NeedTypes | packages.NeedName |
+		packages.NeedSyntax | packages.NeedFiles | packages.NeedModule
 )
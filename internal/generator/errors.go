@@ -28,3 +28,30 @@ func IsErrorPackageNotFound(err error) bool {
 
 // ErrorMockNotFound may be returned if no mock was found.
 const ErrorMockNotFound errors.Const = "mock was not found"
+
+// ErrorMockConflict is returned when mocks found are bound to different
+// controller packages, e.g. both github.com/golang/mock and go.uber.org/mock
+// are used.
+type ErrorMockConflict struct {
+	used  string
+	found string
+}
+
+func (e ErrorMockConflict) Error() string {
+	return fmt.Sprintf(
+		"mock controller package conflict: %s is used already, mock with %s controller found",
+		e.used,
+		e.found,
+	)
+}
+
+// Is to support custom handling for errors.Is.
+func (e ErrorMockConflict) Is(err error) bool {
+	_, ok := err.(ErrorMockConflict)
+	return ok
+}
+
+// IsErrorMockConflict tests an error to be ErrorMockConflict.
+func IsErrorMockConflict(err error) bool {
+	return errors.Is(err, ErrorMockConflict{})
+}
@@ -1,14 +1,15 @@
 package generator
 
 import (
+	"context"
 	"go/token"
 	"go/types"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/sirkon/errors"
 	"github.com/sirkon/gogh"
-	"github.com/sirkon/jsonexec"
 	"github.com/sirkon/message"
 	"github.com/sirkon/ttgenlib/internal/ordmap"
 	"golang.org/x/tools/go/packages"
@@ -20,15 +21,50 @@ type Generator struct {
 	fset       *token.FileSet
 	pkg        *packages.Package
 	pkgs       map[string]*packages.Package
+	imported   map[string]*types.Package
 	mockLookup MockLookup
-
-	nomock      []doNotMock
-	m           *gogh.Module[*gogh.Imports]
-	mockerNames func(tn *types.TypeName) (filename string, typename string)
-
-	preTest func(r *goRenderer)
-	ctxInit func(r *goRenderer)
-	msgr    LoggingRenderer
+	mockPaths  []string
+	backend    MockBackend
+	mocks      map[*types.Named]MockLookupResult
+
+	mockGen        *mockGeneration
+	generatedMocks []string
+
+	missingMocks MissingMockPolicy
+	degraded     []string
+
+	ctx        context.Context
+	overlay    map[string][]byte
+	cache      *Cache
+	preload    []string
+	preloaded  map[string]struct{}
+	sourceLoad bool
+
+	dryRun func(files []RenderedFile) error
+	stage  *stage
+
+	nomock          []doNotMock
+	embeddedStructs bool
+	instances       [][]string
+	m               *gogh.Module[*gogh.Imports]
+	mockerNames     func(tn *types.TypeName) (filename string, typename string)
+
+	testFile  string
+	external  bool
+	testFiles map[string]*goRenderer
+	mockers   map[string]struct{}
+
+	existing      ExistingPolicy
+	existingTests map[string]map[string]struct{}
+	replacements  []replacement
+
+	preTest   func(r *goRenderer)
+	ctxInit   ContextInitRenderer
+	ctxParam  ContextParamRenderer
+	ctxSetup  bool
+	ctxCancel bool
+	parallel  bool
+	msgr      LoggingRenderer
 }
 
 func newGenerator(
@@ -37,31 +73,14 @@ func newGenerator(
 	msgsRenderer LoggingRenderer,
 	opts ...Option,
 ) (*Generator, error) {
-	var goList struct {
-		ImportPath string
-	}
-	if err := jsonexec.Run(&goList, "go", "list", "--json", pkg); err != nil {
-		return nil, errors.Wrapf(err, "get package '%s' info", pkg)
-	}
-
-	cfg := &packages.Config{
-		Mode:    PackageLoadMode,
-		Context: nil,
-		Logf: func(format string, args ...interface{}) {
-			message.Infof(format, args...)
-		},
-		Tests: false,
-	}
-	res, err := packages.Load(cfg, goList.ImportPath)
-	if err != nil {
-		return nil, errors.Wrap(err, "parse package")
-	}
-
 	g := &Generator{
-		path:       goList.ImportPath,
-		pkg:        nil,
+		ctx:        context.Background(),
 		mockLookup: mockLookup,
+		backend:    NewGomockBackend(),
+		mocks:      map[*types.Named]MockLookupResult{},
 		pkgs:       map[string]*packages.Package{},
+		imported:   map[string]*types.Package{},
+		preloaded:  map[string]struct{}{},
 		nomock:     []doNotMock{contextNoMock},
 		mockerNames: func(tn *types.TypeName) (filename string, typename string) {
 			filename = gogh.Underscored(tn.Name(), "mocker", "test") + ".go"
@@ -69,20 +88,13 @@ func newGenerator(
 
 			return filename, typename
 		},
-		preTest: func(r *goRenderer) {},
-		ctxInit: func(r *goRenderer) {
-			r.Imports().Add("context").Ref("ctx")
-			r.L(`ctx := $ctx.Background()`)
-		},
-		msgr: msgsRenderer,
-	}
-	for _, pg := range res {
-		if pg.PkgPath == goList.ImportPath {
-			g.pkg = pg
-			g.fset = g.pkg.Fset
-		}
-		g.pkgs[pg.PkgPath] = pg
+		preTest:       func(r *goRenderer) {},
+		msgr:          msgsRenderer,
+		testFiles:     map[string]*goRenderer{},
+		mockers:       map[string]struct{}{},
+		existingTests: map[string]map[string]struct{}{},
 	}
+	g.ctxInit = g.defaultCtxInit
 
 	for _, opt := range opts {
 		if err := opt(g, optionRestriction{}); err != nil {
@@ -90,91 +102,309 @@ func newGenerator(
 		}
 	}
 
-	m, err := gogh.New(
+	if !g.sourceLoad {
+		if err := checkExportData(); err != nil {
+			message.Debugf("export data cannot be used, loading sources of dependencies: %s", err)
+			g.sourceLoad = true
+		}
+	}
+
+	m, err := newModule()
+	if err != nil {
+		return nil, errors.Wrap(err, "init code renderer for the module")
+	}
+	g.m = m
+
+	target, err := g.loadTarget(pkg)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse package")
+	}
+
+	if target.Module == nil || target.Module.Dir == "" {
+		return nil, errors.Newf("package %s is not in a module, only module packages are supported", target.PkgPath)
+	}
+
+	g.path = target.PkgPath
+	g.pkg = target
+	g.fset = target.Fset
+
+	if b, ok := g.backend.(*gomockBackend); ok && !b.fixed && target.Module.GoMod != "" {
+		// Generated code refers to the gomock package before any mock is found.
+		data, err := g.readFile(target.Module.GoMod)
+		if err != nil {
+			return nil, errors.Wrap(err, "read module file")
+		}
+
+		b.required, err = requiredGomock(target.Module.GoMod, data)
+		if err != nil {
+			return nil, errors.Wrap(err, "look for gomock module required")
+		}
+	}
+
+	if g.dryRun != nil {
+		// Code is rendered into a scratch copy of the module in the dry run mode.
+		s, err := g.newStage()
+		if err != nil {
+			return nil, errors.Wrap(err, "set up scratch module to render into")
+		}
+
+		m, err := s.newModule()
+		if err != nil {
+			s.remove()
+			return nil, errors.Wrap(err, "init code renderer for the scratch module")
+		}
+
+		g.stage = s
+		g.m = m
+	}
+
+	return g, nil
+}
+
+// newModule creates a code renderer for the module, it is the one of the
+// working directory unless the root is given with options.
+func newModule(opts ...gogh.ModuleOption[*gogh.Imports]) (*gogh.Module[*gogh.Imports], error) {
+	return gogh.New(
 		gogh.FancyFmt,
 		func(r *gogh.Imports) *gogh.Imports {
 			return r
 		},
+		opts...,
 	)
+}
+
+// cleanup removes what the generator left behind.
+func (g *Generator) cleanup() {
+	if g.stage != nil {
+		g.stage.remove()
+	}
+}
+
+// generateFor generates tests for the function or method f. Tests are appended
+// to the test file paired with the source file f is defined in, the _external_test.go
+// one for external tests.
+func (g *Generator) generateFor(p *goPackage, f *types.Func) error {
+	testFile := g.testFile
+	switch {
+	case testFile != "":
+	case g.external:
+		// The paired _test.go file is likely to hold internal tests already.
+		testFile = strings.TrimSuffix(g.digObjectFile(f), ".go") + "_external_test.go"
+	default:
+		testFile = strings.TrimSuffix(g.digObjectFile(f), ".go") + "_test.go"
+	}
+	r, ok := g.testFiles[testFile]
+	if !ok {
+		if err := g.checkTestFile(testFile); err != nil {
+			return err
+		}
+
+		var err error
+		r, err = p.Reuse(testFile)
+		if err != nil {
+			return errors.Wrap(err, "prepare test file")
+		}
+
+		g.testFiles[testFile] = r
+	}
+
+	targets, err := g.targets(f)
 	if err != nil {
-		return nil, errors.Wrap(err, "init code renderer for the module")
+		return errors.Wrap(err, "prepare test targets")
 	}
-	g.m = m
 
-	return g, nil
+	for _, t := range targets {
+		if g.external {
+			if err := g.checkExternal(t); err != nil {
+				return err
+			}
+		}
+
+		name := "Test" + t.name()
+		exists, err := g.testExists(testFile, name)
+		if err != nil {
+			return errors.Wrap(err, "check for existing test")
+		}
+
+		if exists && g.existing == ExistingSkip {
+			message.Infof("%s already exists in %s, skipping", name, testFile)
+			continue
+		}
+
+		if err := g.generate(p, r, t); err != nil {
+			return errors.Wrap(err, "generate source code")
+		}
+
+		if exists {
+			// The test is only replaced once the new one was generated.
+			g.replacements = append(g.replacements, replacement{
+				file:  filepath.Join(g.pkgDir(), testFile),
+				name:  name,
+				call:  t.obj.Name(),
+				force: g.existing == ExistingForce,
+			})
+		}
+	}
+
+	return nil
 }
 
-func (g *Generator) generate(p *goPackage, r *goRenderer, f types.Object) error {
-	s := f.Type().(*types.Signature)
+// render renders generated code and replaces previously existing tests
+// with the regenerated ones. Files are passed to the dry run handler
+// instead of being written if it was set.
+func (g *Generator) render() error {
+	if g.dryRun != nil {
+		files, err := g.renderDry()
+		if err != nil {
+			return err
+		}
 
-	typeMocks, err := g.getMocksOfType(s)
-	if err != nil {
-		return errors.Wrap(err, "get mocks of type")
+		if err := g.dryRun(files); err != nil {
+			return errors.Wrap(err, "handle rendered files")
+		}
+	} else if err := g.renderFiles(); err != nil {
+		return err
 	}
 
-	if len(typeMocks) > 0 {
-		if err := g.generateTypeMocker(p, s, typeMocks); err != nil {
-			return errors.Wrap(err, "generate mocker for the type")
+	g.reportGeneratedMocks()
+	g.reportMissingMocks()
+
+	return nil
+}
+
+// renderFiles writes generated code into files.
+func (g *Generator) renderFiles() error {
+	if err := g.m.Render(); err != nil {
+		return errors.Wrap(err, "render generated source code")
+	}
+
+	if err := g.replaceExisting(); err != nil {
+		return errors.Wrap(err, "replace existing tests")
+	}
+
+	return nil
+}
+
+func (g *Generator) generate(p *goPackage, r *goRenderer, t target) error {
+	// Look for all mocks needed before rendering anything, so nothing
+	// is left half-done if some of them were not found. Receiver fields
+	// are out of reach in the external test package, so the receiver is
+	// left to the user there.
+	var typeMocks []fieldMock
+	var missingFields []missingMock
+	var ctxFields []*types.Var
+	if !g.external {
+		var err error
+		typeMocks, missingFields, err = g.getMocksOfType(t)
+		if err != nil {
+			return errors.Wrap(err, "get mocks of type")
 		}
+
+		ctxFields = g.contextFields(t)
 	}
 
-	paramMocks, err := g.getMocksOfArguments(s, f)
+	paramMocks, missingParams, err := g.getMocksOfArguments(t)
 	if err != nil {
 		return errors.Wrap(err, "get mocks for arguments")
 	}
+	missing := append(missingFields, missingParams...)
 
-	g.generateTest(r, f, len(typeMocks) > 0, paramMocks)
+	if len(typeMocks) > 0 {
+		if err := g.generateTypeMocker(p, t, typeMocks); err != nil {
+			return errors.Wrap(err, "generate mocker for the type")
+		}
+	}
+
+	g.generateTest(r, t, len(typeMocks) > 0, paramMocks, missing, ctxFields)
+	g.recordMissingMocks(t, missing)
 
 	return nil
 }
 
 func (g *Generator) generateTest(
 	r *goRenderer,
-	f types.Object,
+	t target,
 	hasMocksInType bool,
 	amocks []MockLookupResult,
+	missing []missingMock,
+	ctxFields []*types.Var,
 ) {
-	s := f.Type().(*types.Signature)
-	var mtype *types.Named
+	s := t.sig
+	mtype := t.recv
+	typ := g.typeFunc(r)
 	if hasMocksInType {
-		mtype = s.Recv().Type().(*types.Pointer).Elem().(*types.Named)
-		_, mockertype := g.mockerNames(mtype.Obj())
+		_, mockertype := g.mockerTypeNames(t)
 		r.Let("mockertype", mockertype)
 	}
 
 	r.Imports().Add("testing").Ref("tst")
 
-	if mtype != nil {
-		r.L(`func Test${0}${1}(t *${tst}.T) {`, mtype.Obj().Name(), f.Name())
-	} else {
-		r.L(`func Test${0}(t *${tst}.T) {`, f.Name())
+	r.L(`func Test${0}(t *${tst}.T) {`, t.name())
+	if g.parallel {
+		r.L(`    t.Parallel()`)
+		r.N()
 	}
 
-	argfields, resfields, errcheck := g.renderTestStructure(r, hasMocksInType, mtype, amocks, s)
+	withCtx := usesContext(t, ctxFields)
+	ctxParams := contextParams(s)
+	argfields, resfields, errcheck, rowctx := g.renderTestStructure(r, hasMocksInType, mtype, amocks, missing, withCtx, s)
 
 	r.N()
 	r.L(`    tests := []test{}`)
 	r.L(`    for _, tt := range tests {`)
-	r.L(`        tt := tt`)
+	if !g.goVersionAtLeast("1.22") {
+		// Loop variables are shared between iterations before Go 1.22.
+		r.L(`        tt := tt`)
+	}
 	r.L(`        t.Run(tt.name, func(t *$tst.T) {`)
+	if g.parallel {
+		// Everything below is created for each subtest, nothing is shared.
+		r.L(`            t.Parallel()`)
+		r.N()
+	}
+	var ctrl string
 	if hasMocksInType || len(amocks) > 0 {
-		r.Imports().Add(gomockPath).Ref("gomock")
-		r.L(`            ctrl := $gomock.NewController(t)`)
+		ctrl = g.backend.Controller(r)
 	}
 	g.preTest(r)
-	if hasContextArg(s) {
-		g.ctxInit(r)
-	}
-	if hasMocksInType {
-		r.L(`            m := new${mockertype|P}(ctrl)`, mtype.Obj().Name())
+	if withCtx {
+		g.ctxInit(r, ContextInit{
+			Test:   "t",
+			Kind:   TestKindTest,
+			Params: ctxParams,
+			Fields: fieldNames(ctxFields),
+		})
+		rowctx.render(r)
+	}
+	switch {
+	case hasMocksInType:
+		r.L(`            m := new${mockertype|P}($0)`, ctrl)
 		r.L(`            x := m.$0()`, mtype.Obj().Name())
-	} else if mtype != nil {
-		r.L(`            var x *$type // User change required, it is unclear how to create it properly'.`)
+	case mtype != nil && t.ptrRecv && (len(ctxFields) > 0 || setsFields(missing)):
+		// Fields are set below, a nil pointer cannot have them.
+		r.L(`            x := &$0{} // User change required, it is unclear how to create it properly.`, typ(mtype))
+	case mtype != nil:
+		r.L(`            var x $0 // User change required, it is unclear how to create it properly.`, t.recvType(typ))
+	}
+	// Contexts kept in the receiver are not mocked, the test one is used.
+	for _, f := range ctxFields {
+		r.L(`            x.$0 = ctx`, f.Name())
+	}
+	for _, m := range missing {
+		if len(m.path) == 0 {
+			continue
+		}
+
+		if m.field != "" {
+			r.L(`            x.$0 = tt.$1`, m.fieldPath(), m.field)
+		} else {
+			r.L(`            // TODO: no mock for $0, set x.$1 manually.`, typ(m.typ), m.fieldPath())
+		}
 	}
 	if len(amocks) > 0 {
 		r.L(`            amocks := argMocks{`)
 		for _, amock := range amocks {
-			r.L(`            $0: $1(ctrl),`, amock.Name, strings.TrimSuffix(r.Type(amock.Named), amock.Named.Obj().Name())+amock.Constructor.Name())
+			r.L(`            $0: $1,`, amock.Name, g.newMock(r, amock, ctrl))
 		}
 		r.L(`            }`)
 	}
@@ -182,7 +412,9 @@ func (g *Generator) generateTest(
 	// Must call setup with proper parameters.
 	if hasMocksInType || len(amocks) > 0 {
 		sp := &gogh.Commas{}
-		sp.Add("ctrl")
+		if ctrl != "" {
+			sp.Add(ctrl)
+		}
 		sp.Add("&tt")
 		if hasMocksInType {
 			sp.Add("m")
@@ -190,8 +422,10 @@ func (g *Generator) generateTest(
 		if len(amocks) > 0 {
 			sp.Add("&amocks")
 		}
-		if mtype != nil {
+		if mtype != nil && t.ptrRecv {
 			sp.Add("x")
+		} else if mtype != nil {
+			sp.Add("&x")
 		}
 
 		r.L(`tt.setup($0)`, sp)
@@ -201,15 +435,15 @@ func (g *Generator) generateTest(
 
 	// Set up call params.
 	cp := &gogh.Commas{}
+	var nctx int
 outer:
 	for i := 0; i < s.Params().Len(); i++ {
 		p := s.Params().At(i)
 		if isContext(p.Type()) {
-			// All context.Context params will be stuffed with the same ctx.
-			// It is possible to have two or more at once, at least nothing
-			// can prevent some random coder from making so. To be resolved
-			// manually then.
-			cp.Add("ctx")
+			// All context.Context params are stuffed with the same ctx unless
+			// the renderer for them was set.
+			cp.Add(g.renderCtxParam(r, ctxParams[nctx]))
+			nctx++
 			continue
 		}
 
@@ -220,17 +454,32 @@ outer:
 			}
 		}
 
+		if m, ok := findMissingParam(missing, p.Name()); ok && g.missingMocks == MissingMockTODO {
+			r.L(`// TODO: no mock for $0, nil is passed as $1.`, typ(m.typ), p.Name())
+			cp.Add("nil")
+			continue
+		}
+
 		name := argfields.MustGet(p.Name())
+		if s.Variadic() && i == s.Params().Len()-1 {
+			cp.Add("tt." + name + "...")
+			continue
+		}
+
 		cp.Add("tt." + name)
 	}
 
 	var recvPrefix string
-	if s.Recv() != nil {
+	switch {
+	case s.Recv() != nil:
 		recvPrefix = "x."
+	case g.external:
+		r.Imports().Add(g.path).Ref("tested")
+		recvPrefix = r.S("$tested.")
 	}
 
 	if s.Results().Len() == 0 {
-		r.L(`$0$1($2)`, recvPrefix, f.Name(), cp)
+		r.L(`$0$1($2)`, recvPrefix, t.funcRef(typ), cp)
 	} else {
 		rv := &gogh.Commas{}
 		var results []string
@@ -247,7 +496,7 @@ outer:
 			results = append(results, gotname)
 		}
 
-		r.L(`$0 := $1$2($3)`, rv, recvPrefix, f.Name(), cp)
+		r.L(`$0 := $1$2($3)`, rv, recvPrefix, t.funcRef(typ), cp)
 		if isErrored(s) {
 			r.L(`switch {`)
 			r.L(`case err != nil && (tt.wantErr || tt.$0 != nil):`, errcheck)
@@ -277,6 +526,10 @@ outer:
 
 			wantname := resfields.MustGet(i)
 			gotname := results[i]
+			if collect, _ := g.iterCollector(r, rv.Type()); collect != "" {
+				r.L(`$0Values := $1($0)`, gotname, collect)
+				gotname += "Values"
+			}
 			r.Imports().Add(deepequalPath).Ref("de")
 			r.L(`if !$de.Equal(tt.$0, $1) {`, wantname, gotname)
 			if rv.Name() != "" {
@@ -290,7 +543,7 @@ outer:
 				r.L(
 					`    $de.SideBySide(t, "the return value index $0 ($1)", tt.$2, $3)`,
 					i,
-					r.Type(rv.Type()),
+					typ(rv.Type()),
 					wantname,
 					gotname,
 				)
@@ -310,18 +563,22 @@ func (g *Generator) renderTestStructure(
 	hasMocksInType bool,
 	mtype *types.Named,
 	amocks []MockLookupResult,
+	missing []missingMock,
+	withCtx bool,
 	s *types.Signature,
 ) (
 	argfields *ordmap.OrderedMap[string, string],
 	resfields *ordmap.OrderedMap[int, string],
 	errcheck string,
+	rowctx rowContext,
 ) {
 	r = r.Scope()
+	typ := g.typeFunc(r)
 
 	if len(amocks) > 0 {
 		r.L(`    type argMocks struct{`)
 		for _, amock := range amocks {
-			r.L(`        $0 *$1`, amock.Name, r.Type(amock.Named))
+			r.L(`        $0 *$1`, amock.Name, typ(amock.Named))
 		}
 		r.L(`    }`)
 		r.N()
@@ -332,28 +589,29 @@ func (g *Generator) renderTestStructure(
 
 	r.L(`       name string`)
 
-	// Render setup function arguments.
-	rr := r.Scope()
-	var setupArgs gogh.Params
-	if hasMocksInType {
-		setupArgs.Add(rr.Uniq("ctrl"), r.S("*$gomock.Controller"))
-	}
-	rr.Uniq("row")
-	setupArgs.Add("row", "*test")
-	if hasMocksInType {
-		setupArgs.Add(r.Uniq("m"), r.S("*${mockertype}"))
-	}
-	if len(amocks) > 0 {
-		setupArgs.Add(rr.Uniq("amocks"), "*argMocks")
-	}
-	if s.Recv() != nil {
-		setupArgs.Add(
-			rr.Uniq(gogh.Private(r.Type(mtype))),
-			"*"+r.Type(mtype),
-		)
-	}
-
+	// Render setup function arguments. The controller type imports the mocking
+	// library, so nothing is rendered without mocks.
 	if len(amocks) > 0 || hasMocksInType {
+		rr := r.Scope()
+		var setupArgs gogh.Params
+		if ctrlType := g.backend.ControllerType(r); ctrlType != "" {
+			setupArgs.Add(rr.Uniq("ctrl"), ctrlType)
+		}
+		rr.Uniq("row")
+		setupArgs.Add("row", "*test")
+		if hasMocksInType {
+			setupArgs.Add(r.Uniq("m"), r.S("*${mockertype}"))
+		}
+		if len(amocks) > 0 {
+			setupArgs.Add(rr.Uniq("amocks"), "*argMocks")
+		}
+		if s.Recv() != nil {
+			setupArgs.Add(
+				rr.Uniq(gogh.Private(mtype.Obj().Name())),
+				"*"+typ(mtype),
+			)
+		}
+
 		r.L(`        setup func($0)`, &setupArgs)
 	}
 
@@ -373,9 +631,30 @@ outer:
 			}
 		}
 
+		if _, ok := findMissingParam(missing, param.Name()); ok && g.missingMocks == MissingMockTODO {
+			continue outer
+		}
+
 		argfield := r.Uniq(param.Name(), "arg")
 		argfields.Set(param.Name(), argfield)
-		r.L(`        $0 $1`, argfield, r.Type(param.Type()))
+		r.L(`        $0 $1`, argfield, typ(param.Type()))
+	}
+
+	// Render fields for receiver fields without mocks to be set from test rows.
+	if g.missingMocks == MissingMockValue {
+		for i, m := range missing {
+			if len(m.path) == 0 || !m.accessible(g.path) {
+				continue
+			}
+
+			missing[i].field = r.Uniq(gogh.Private(m.path[len(m.path)-1].Name()))
+			r.L(`        $0 $1`, missing[i].field, typ(m.typ))
+		}
+	}
+
+	// Render fields to derive the test context for a test case with.
+	if withCtx {
+		rowctx = g.renderRowContextFields(r)
 	}
 
 	// Render fields for expected return values and error check.
@@ -400,19 +679,32 @@ outer:
 		}
 		resfields.Set(i, name)
 
-		r.L(`        $0 $1`, name, r.Type(s.Results().At(i).Type()))
+		// Values of iterators are collected to be compared.
+		if _, collection := g.iterCollector(r, res.Type()); collection != "" {
+			r.L(`        $0 $1`, name, collection)
+			continue
+		}
+
+		r.L(`        $0 $1`, name, typ(res.Type()))
 	}
 
 	r.L(`    }`)
 
-	return argfields, resfields, errCheck
+	return argfields, resfields, errCheck, rowctx
 }
 
-func (g *Generator) generateTypeMocker(p *goPackage, s *types.Signature, mocks []MockLookupResult) error {
-	tn := s.Recv().Type().(*types.Pointer).Elem().(*types.Named).Obj()
+func (g *Generator) generateTypeMocker(p *goPackage, t target, mocks []fieldMock) error {
+	tn := t.recv.Obj()
 
-	filename, typename := g.mockerNames(tn)
-	fn := strings.TrimSuffix(filename, ".go") + ".go"
+	fn, typename := g.mockerTypeNames(t)
+	if _, ok := g.mockers[fn]; ok {
+		// Has been generated already for another method of this type.
+		return nil
+	}
+	g.mockers[fn] = struct{}{}
+	if g.parallel {
+		g.warnSharedState(fn)
+	}
 
 	r := p.Go(fn, gogh.Shy)
 
@@ -420,7 +712,6 @@ func (g *Generator) generateTypeMocker(p *goPackage, s *types.Signature, mocks [
 	r.Let("mockertype", typename)
 
 	r.Imports().Add("sync").Ref("sync")
-	r.Imports().Add(gomockPath).Ref("gomock")
 
 	r.Uniq(tn.Name())
 	var fieldNames []string
@@ -430,17 +721,14 @@ func (g *Generator) generateTypeMocker(p *goPackage, s *types.Signature, mocks [
 	wn := r.Uniq("waiter")
 
 	r.L(`// Creates new mocker instance for ${type}.`)
-	r.L(`func new${mockertype|P}(ctrl *$gomock.Controller) *${mockertype} {`)
+	if ctrlType := g.backend.ControllerType(r); ctrlType != "" {
+		r.L(`func new${mockertype|P}(ctrl $0) *${mockertype} {`, ctrlType)
+	} else {
+		r.L(`func new${mockertype|P}() *${mockertype} {`)
+	}
 	r.L(`    return &${mockertype}{`)
 	for i, mock := range mocks {
-		var cname string
-		tname := r.Type(mock.Named)
-		if strings.Contains(tname, ".") {
-			cname = strings.Replace(tname, ".", ".New", 1)
-		} else {
-			cname = "New" + tname
-		}
-		r.L(`        $0: $1(ctrl),`, fieldNames[i], cname)
+		r.L(`        $0: $1,`, fieldNames[i], g.backend.NewMock(r, mock.MockLookupResult, "ctrl"))
 	}
 	r.N()
 	r.L(`    }`)
@@ -462,8 +750,8 @@ func (g *Generator) generateTypeMocker(p *goPackage, s *types.Signature, mocks [
 	r.L(`// end is called in a background process to reduce waiting count which is set by waiters call.`)
 	r.L(`// It should be bound to the last call made in each background process to wait for. Something like:`)
 	r.L(`//`)
-	r.L(`//    m.Mock.EXPECT().….Do(m.end)`)
-	r.L(`func (m *${mockertype}) end(...any) {`)
+	r.L(`//    $0`, g.backend.WaitHint())
+	r.L(`func (m *${mockertype}) end(...$0) {`, g.anyType())
 	r.L(`    m.$0.Done()`, wn)
 	r.L(`}`)
 	r.N()
@@ -473,21 +761,29 @@ func (g *Generator) generateTypeMocker(p *goPackage, s *types.Signature, mocks [
 	r.L(`}`)
 	r.N()
 	r.L(`// ${0|p} creates $0 instance with mocks.`, tn.Name())
-	r.L(`func (m *${mockertype}) ${type}() *$type {`)
-	r.L(`    // User defined.`)
+	r.L(`func (m *${mockertype}) ${type}() $0 {`, t.recvType(r.Type))
+	g.renderTypeConstructor(r, t, mocks, fieldNames)
 	r.L(`}`)
 	r.N()
 
 	return nil
 }
 
-func (g *Generator) getMocksOfArguments(s *types.Signature, f types.Object) (res []MockLookupResult, _ error) {
+func (g *Generator) getMocksOfArguments(t target) (res []MockLookupResult, missing []missingMock, _ error) {
+	s := t.sig
 	for i := 0; i < s.Params().Len(); i++ {
 		p := s.Params().At(i)
 
+		if s.Variadic() && i == s.Params().Len()-1 {
+			// There is no telling how many values are to be passed, so even
+			// interface ones are taken from test cases as they are.
+			g.infoParamVariadicOmit(p.Pos(), p.Name())
+			continue
+		}
+
 		vn, ok := p.Type().(*types.Named)
 		if !ok || !underlyingTypeIs[*types.Interface](vn) {
-			g.infoParamNotInterfaceOmit(p.Pos(), f.Name())
+			g.infoParamNotInterfaceOmit(p.Pos(), p.Name())
 			continue
 		}
 
@@ -496,42 +792,75 @@ func (g *Generator) getMocksOfArguments(s *types.Signature, f types.Object) (res
 		}
 
 		if _, ok := vn.Underlying().(*types.Interface); !ok {
-			g.infoParamNotInterfaceOmit(p.Pos(), f.Name())
+			g.infoParamNotInterfaceOmit(p.Pos(), p.Name())
 			continue
 		}
 
-		mockData, err := g.mockLookup(g, vn)
+		mockData, err := g.lookupMock(vn)
 		if err != nil {
-			return nil, errors.Wrapf(err, "look for a mock for type %s", vn)
+			if !g.degrade(err) {
+				return nil, nil, errors.Wrapf(err, "look for a mock for type %s", vn)
+			}
+
+			missing = append(missing, missingMock{
+				name: p.Name(),
+				typ:  vn,
+				pos:  p.Pos(),
+			})
+			continue
 		}
 
 		mockData.Name = p.Name()
 		res = append(res, mockData)
 	}
 
-	return res, nil
+	return res, missing, nil
 }
 
-func (g *Generator) getMocksOfType(s *types.Signature) (res []MockLookupResult, _ error) {
-	if s.Recv() == nil {
-		return nil, nil
+// fieldMock a mock for a field of the receiver type.
+type fieldMock struct {
+	MockLookupResult
+
+	// path is a chain of fields from the receiver type down to the mocked one.
+	// It is longer than one for fields of embedded structures.
+	path []*types.Var
+}
+
+func (g *Generator) getMocksOfType(tt target) (res []fieldMock, missing []missingMock, _ error) {
+	if tt.recv == nil {
+		return nil, nil, nil
 	}
 
-	var t *types.Struct
-	if vv, ok := s.Recv().Type().(*types.Pointer); ok {
-		t = vv.Elem().(*types.Named).Underlying().(*types.Struct)
-	} else {
-		t = s.Recv().Type().(*types.Named).Underlying().(*types.Struct)
+	t, ok := tt.recv.Underlying().(*types.Struct)
+	if !ok {
+		message.Debugf("%s receiver type is not a structure, no fields to mock", g.fset.Position(tt.recv.Obj().Pos()))
+		return nil, nil, nil
 	}
+
+	return g.getMocksOfFields(t, nil, map[*types.Named]struct{}{tt.recv.Origin(): {}})
+}
+
+func (g *Generator) getMocksOfFields(
+	t *types.Struct,
+	path []*types.Var,
+	visited map[*types.Named]struct{},
+) (res []fieldMock, missing []missingMock, _ error) {
 	for i := 0; i < t.NumFields(); i++ {
 		f := t.Field(i)
+		fpath := append(path[:len(path):len(path)], f)
 
-		if f.Anonymous() {
-			message.Warningf("%s embedded fields are not supported", g.fset.Position(f.Pos()))
+		vn, ok := f.Type().(*types.Named)
+		if f.Anonymous() && (!ok || !underlyingTypeIs[*types.Interface](vn)) {
+			mocks, miss, err := g.getMocksOfEmbedded(f, fpath, visited)
+			if err != nil {
+				return res, missing, errors.Wrapf(err, "get mocks of embedded %s", f.Name())
+			}
+
+			res = append(res, mocks...)
+			missing = append(missing, miss...)
 			continue
 		}
 
-		vn, ok := f.Type().(*types.Named)
 		if !ok || !underlyingTypeIs[*types.Interface](vn) {
 			g.infoFieldNotInterfaceOmit(f.Pos(), f.Name())
 			continue
@@ -541,14 +870,67 @@ func (g *Generator) getMocksOfType(s *types.Signature) (res []MockLookupResult,
 			continue
 		}
 
-		mockData, err := g.mockLookup(g, vn)
+		mockData, err := g.lookupMock(vn)
 		if err != nil {
-			return res, errors.Wrapf(err, "look for a mock for type %s", vn)
+			if !g.degrade(err) {
+				return res, missing, errors.Wrapf(err, "look for a mock for type %s", vn)
+			}
+
+			missing = append(missing, missingMock{
+				name: f.Name(),
+				typ:  vn,
+				pos:  f.Pos(),
+				path: fpath,
+			})
+			continue
 		}
 
+		// Embedded interfaces have the type name as the field name, this
+		// is not a good name for a mock.
 		mockData.Name = f.Name()
-		res = append(res, mockData)
+		if f.Anonymous() {
+			mockData.Name = gogh.Private(f.Name())
+		}
+		res = append(res, fieldMock{
+			MockLookupResult: mockData,
+			path:             fpath,
+		})
+	}
+
+	return res, missing, nil
+}
+
+// getMocksOfEmbedded collects mocks for fields of embedded structures if this was enabled.
+func (g *Generator) getMocksOfEmbedded(
+	f *types.Var,
+	path []*types.Var,
+	visited map[*types.Named]struct{},
+) ([]fieldMock, []missingMock, error) {
+	if !g.embeddedStructs {
+		message.Debugf("%s embedded field %s is not an interface, omitting", g.fset.Position(f.Pos()), f.Name())
+		return nil, nil, nil
+	}
+
+	ft := f.Type()
+	if p, ok := ft.(*types.Pointer); ok {
+		ft = p.Elem()
+	}
+
+	vn, ok := ft.(*types.Named)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	st, ok := vn.Underlying().(*types.Struct)
+	if !ok {
+		message.Debugf("%s embedded field %s is not a structure, omitting", g.fset.Position(f.Pos()), f.Name())
+		return nil, nil, nil
+	}
+
+	if _, ok := visited[vn.Origin()]; ok {
+		return nil, nil, nil
 	}
+	visited[vn.Origin()] = struct{}{}
 
-	return res, nil
+	return g.getMocksOfFields(st, path, visited)
 }
@@ -0,0 +1,276 @@
+package generator
+
+import (
+	"go/build"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/message"
+	"golang.org/x/tools/go/gcexportdata"
+	"golang.org/x/tools/go/packages"
+)
+
+// Cache keeps loaded packages between generations, so long living processes
+// like language servers do not load them over and over. It is safe for
+// concurrent use. It lives in memory only: single runs rely on export data
+// the go build cache keeps on disk instead.
+type Cache struct {
+	lock  sync.Mutex
+	loads map[string]*cachedLoad
+}
+
+// NewCache creates an empty packages cache.
+func NewCache() *Cache {
+	return &Cache{
+		loads: map[string]*cachedLoad{},
+	}
+}
+
+// cachedLoad a result of a single packages load.
+type cachedLoad struct {
+	pkgs  []*packages.Package
+	files map[string]struct{}
+	dirs  map[string]struct{}
+}
+
+// Invalidate drops loads having the package of the file among loaded packages
+// or their dependencies, as well as ones whose module file is changed. Changes
+// elsewhere in a module leave its loads intact. Call it when the file is
+// changed.
+func (c *Cache) Invalidate(file string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for pattern, l := range c.loads {
+		if l.affected(file) {
+			delete(c.loads, pattern)
+		}
+	}
+}
+
+// affected checks if the change of the file can affect loaded packages.
+func (l *cachedLoad) affected(file string) bool {
+	if _, ok := l.files[file]; ok {
+		return true
+	}
+
+	// The file may be new to a loaded package.
+	_, ok := l.dirs[filepath.Dir(file)]
+	return ok
+}
+
+func (c *Cache) get(pattern string) *cachedLoad {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.loads[pattern]
+}
+
+func (c *Cache) put(pattern string, l *cachedLoad) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.loads[pattern] = l
+}
+
+// load loads packages matching patterns in one go. Loaded packages are taken from
+// the cache and put there if it is set.
+func (g *Generator) load(patterns ...string) ([]*packages.Package, error) {
+	key := strings.Join(patterns, " ")
+	if g.cache != nil {
+		if l := g.cache.get(key); l != nil {
+			return l.pkgs, nil
+		}
+	}
+
+	res, err := packages.Load(g.packagesConfig(), patterns...)
+	if err != nil {
+		return nil, errors.Wrap(err, "load packages")
+	}
+
+	if g.cache != nil {
+		l := &cachedLoad{
+			pkgs:  res,
+			files: map[string]struct{}{},
+			dirs:  map[string]struct{}{},
+		}
+		packages.Visit(res, nil, func(p *packages.Package) {
+			for _, file := range p.GoFiles {
+				l.files[file] = struct{}{}
+				l.dirs[filepath.Dir(file)] = struct{}{}
+			}
+
+			if p.Module != nil && p.Module.GoMod != "" {
+				l.files[p.Module.GoMod] = struct{}{}
+			}
+		})
+		g.cache.put(key, l)
+	}
+
+	return res, nil
+}
+
+// loadTarget loads the package tests are generated for along with packages to
+// preload and returns the former. Preloading is given up if it fails.
+func (g *Generator) loadTarget(pattern string) (*packages.Package, error) {
+	patterns := append([]string{pattern}, g.preloadPatterns(g.preload)...)
+
+	res, err := g.load(patterns...)
+	if err != nil && len(patterns) > 1 {
+		message.Warning(errors.Wrap(err, "preload packages"))
+		res, err = g.load(pattern)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var target *packages.Package
+	for _, p := range res {
+		if rootMatches(pattern, p) {
+			target = p
+			continue
+		}
+
+		// Packages to preload may be missing.
+		if len(p.Errors) == 0 {
+			g.register(p)
+		}
+	}
+	if target == nil {
+		return nil, errors.Newf("no package found for %s", pattern)
+	}
+
+	g.register(target)
+	return target, nil
+}
+
+// preloadPackages loads packages given by import paths or paths relative to the
+// module root which are not known yet in one go. Packages failed to load are left
+// for lookups to report.
+func (g *Generator) preloadPackages(paths []string) {
+	var missing []string
+	for _, p := range paths {
+		if _, ok := g.preloaded[p]; ok {
+			continue
+		}
+
+		g.preloaded[p] = struct{}{}
+		if _, ok := g.pkgs[p]; ok {
+			continue
+		}
+		if _, ok := g.imported[p]; ok {
+			continue
+		}
+
+		missing = append(missing, p)
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	res, err := g.load(g.preloadPatterns(missing)...)
+	if err != nil {
+		message.Warning(errors.Wrap(err, "preload packages"))
+		return
+	}
+
+	for _, p := range res {
+		if len(p.Errors) == 0 {
+			g.register(p)
+		}
+	}
+}
+
+// preloadPatterns returns patterns to load packages with, paths can be
+// either import paths or relative to the module root.
+func (g *Generator) preloadPatterns(paths []string) []string {
+	var res []string
+	for _, p := range paths {
+		res = append(res, p)
+		if local := path.Join(g.m.Name(), p); local != p {
+			res = append(res, local)
+		}
+	}
+
+	return res
+}
+
+// register makes the package and its complete dependencies available for lookups.
+func (g *Generator) register(p *packages.Package) {
+	g.pkgs[p.PkgPath] = p
+	if p.Types == nil {
+		return
+	}
+
+	var walk func(pkg *types.Package)
+	walk = func(pkg *types.Package) {
+		for _, imp := range pkg.Imports() {
+			if _, ok := g.imported[imp.Path()]; ok || !imp.Complete() {
+				continue
+			}
+
+			g.imported[imp.Path()] = imp
+			walk(imp)
+		}
+	}
+	walk(p.Types)
+}
+
+// rootMatches checks if the loaded package was requested with the pattern,
+// which is either an import path or a directory.
+func rootMatches(pattern string, p *packages.Package) bool {
+	if !build.IsLocalImport(pattern) && !filepath.IsAbs(pattern) {
+		return p.PkgPath == pattern
+	}
+
+	dir, err := filepath.Abs(pattern)
+	if err != nil {
+		return false
+	}
+
+	return p.Dir == dir
+}
+
+var (
+	exportDataOnce  sync.Once
+	exportDataError error
+)
+
+// checkExportData checks if export data of the go toolchain can be read. Packages
+// loading fails fatally otherwise, this happens when golang.org/x/tools is older
+// than the toolchain.
+func checkExportData() error {
+	exportDataOnce.Do(func() {
+		out, err := exec.Command("go", "list", "-export", "-f", "{{.Export}}", "errors").Output()
+		if err != nil {
+			exportDataError = errors.Wrap(err, "get export data file of the errors package")
+			return
+		}
+
+		file, err := os.Open(strings.TrimSpace(string(out)))
+		if err != nil {
+			exportDataError = errors.Wrap(err, "open export data file")
+			return
+		}
+		defer file.Close()
+
+		r, err := gcexportdata.NewReader(file)
+		if err != nil {
+			exportDataError = errors.Wrap(err, "read export data")
+			return
+		}
+
+		if _, err := gcexportdata.Read(r, token.NewFileSet(), map[string]*types.Package{}, "errors"); err != nil {
+			exportDataError = errors.Wrap(err, "decode export data")
+		}
+	})
+
+	return exportDataError
+}
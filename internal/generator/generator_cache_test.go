@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCachedLoadAffected(t *testing.T) {
+	root := filepath.FromSlash("/src/app")
+	l := &cachedLoad{
+		files: map[string]struct{}{
+			filepath.FromSlash("/gopath/src/lib/lib.go"): {},
+			filepath.Join(root, "app.go"):                {},
+			filepath.Join(root, "go.mod"):                {},
+		},
+		dirs: map[string]struct{}{
+			filepath.FromSlash("/gopath/src/lib"): {},
+			root:                                  {},
+		},
+	}
+
+	tests := []struct {
+		name string
+		file string
+		want bool
+	}{
+		{
+			name: "source of a loaded package",
+			file: filepath.FromSlash("/gopath/src/lib/lib.go"),
+			want: true,
+		},
+		{
+			name: "new file of a loaded package",
+			file: filepath.Join(root, "app_new.go"),
+			want: true,
+		},
+		{
+			name: "unrelated package of the module",
+			file: filepath.Join(root, "internal", "other", "other.go"),
+			want: false,
+		},
+		{
+			name: "module file",
+			file: filepath.Join(root, "go.mod"),
+			want: true,
+		},
+		{
+			name: "sibling directory with the same prefix",
+			file: filepath.FromSlash("/src/application/main.go"),
+			want: false,
+		},
+		{
+			name: "outside of the module",
+			file: filepath.FromSlash("/src/other/other.go"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.affected(tt.file); got != tt.want {
+				t.Errorf("affected(%s) = %v, want %v", tt.file, got, tt.want)
+			}
+		})
+	}
+}
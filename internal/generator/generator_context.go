@@ -0,0 +1,161 @@
+package generator
+
+import (
+	"go/types"
+
+	"github.com/sirkon/gogh"
+)
+
+// ContextParam describes a context.Context parameter of the function under test.
+type ContextParam struct {
+	// Name is a name of the parameter.
+	Name string
+	// Index is a position of the parameter in the signature.
+	Index int
+	// Nth is a position of the parameter among context.Context ones, starting from 0.
+	Nth int
+}
+
+// TestKind a kind of the testing function.
+type TestKind int
+
+const (
+	// TestKindTest is for TestXxx(t *testing.T) functions.
+	TestKindTest TestKind = iota
+)
+
+// ContextInit describes where the test context is initialized.
+type ContextInit struct {
+	// Test is a name of the testing variable, like t.
+	Test string
+	// Kind is a kind of the testing function. Only tests are generated for now.
+	Kind TestKind
+	// Params are context.Context parameters of the function under test filled with the context.
+	Params []ContextParam
+	// Fields are names of context.Context fields of the receiver set to the context.
+	Fields []string
+}
+
+// ContextInitRenderer renders initialization of the ctx variable.
+type ContextInitRenderer func(r *gogh.GoRenderer[*gogh.Imports], c ContextInit)
+
+// ContextParamRenderer renders a context to pass as the parameter and returns an
+// expression for it. The ctx variable is available in the generation scope.
+type ContextParamRenderer func(r *gogh.GoRenderer[*gogh.Imports], p ContextParam) string
+
+// contextParams returns context.Context parameters of the signature.
+func contextParams(s *types.Signature) []ContextParam {
+	var res []ContextParam
+	for i := 0; i < s.Params().Len(); i++ {
+		p := s.Params().At(i)
+		if !isContext(p.Type()) {
+			continue
+		}
+
+		res = append(res, ContextParam{
+			Name:  p.Name(),
+			Index: i,
+			Nth:   len(res),
+		})
+	}
+
+	return res
+}
+
+// contextFields collects context.Context fields of the receiver structure when contexts
+// are not mocked. They are set to the test context.
+func (g *Generator) contextFields(t target) []*types.Var {
+	if t.recv == nil {
+		return nil
+	}
+
+	s, ok := t.recv.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+
+	var res []*types.Var
+	for i := 0; i < s.NumFields(); i++ {
+		f := s.Field(i)
+		if !isContext(f.Type()) || !g.shouldNotBeMocked(f.Type().(*types.Named)) {
+			continue
+		}
+
+		res = append(res, f)
+	}
+
+	return res
+}
+
+func fieldNames(fields []*types.Var) []string {
+	var res []string
+	for _, f := range fields {
+		res = append(res, f.Name())
+	}
+
+	return res
+}
+
+// usesContext checks if the test needs ctx.
+func usesContext(t target, fields []*types.Var) bool {
+	return hasContextArg(t.sig) || len(fields) > 0
+}
+
+// renderCtxParam renders a context for the parameter and returns an expression to pass.
+func (g *Generator) renderCtxParam(r *goRenderer, p ContextParam) string {
+	if g.ctxParam == nil {
+		return "ctx"
+	}
+
+	return g.ctxParam(r, p)
+}
+
+// rowContext names of test case fields the test context is derived with.
+type rowContext struct {
+	setup   string
+	timeout string
+	cancel  string
+}
+
+// renderRowContextFields renders fields of the test structure to derive the
+// test context with if this was enabled.
+func (g *Generator) renderRowContextFields(r *goRenderer) (res rowContext) {
+	if g.ctxSetup {
+		r.Imports().Add("context").Ref("ctx")
+		res.setup = r.Uniq("ctxSetup")
+		r.L(`        $0 func(ctx $ctx.Context) $ctx.Context`, res.setup)
+	}
+
+	if g.ctxCancel {
+		r.Imports().Add("time").Ref("time")
+		res.timeout = r.Uniq("ctxTimeout")
+		res.cancel = r.Uniq("cancelBeforeCall")
+		r.L(`        $0 $time.Duration`, res.timeout)
+		r.L(`        $0 bool`, res.cancel)
+	}
+
+	return res
+}
+
+// render renders derivation of ctx for the test case.
+func (c rowContext) render(r *goRenderer) {
+	if c.setup != "" {
+		r.L(`            if tt.$0 != nil {`, c.setup)
+		r.L(`                ctx = tt.$0(ctx)`, c.setup)
+		r.L(`            }`)
+	}
+
+	if c.timeout != "" {
+		r.Imports().Add("context").Ref("ctx")
+		r.L(`            if tt.$0 > 0 {`, c.timeout)
+		r.L(`                var cancel $ctx.CancelFunc`)
+		r.L(`                ctx, cancel = $ctx.WithTimeout(ctx, tt.$0)`, c.timeout)
+		r.L(`                defer cancel()`)
+		r.L(`            }`)
+		r.L(`            if tt.$0 {`, c.cancel)
+		r.L(`                var cancel $ctx.CancelFunc`)
+		r.L(`                ctx, cancel = $ctx.WithCancel(ctx)`)
+		r.L(`                cancel()`)
+		r.L(`            }`)
+	}
+}
@@ -0,0 +1,534 @@
+package generator
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/message"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// ExistingPolicy defines how to deal with tests that were generated before.
+type ExistingPolicy int
+
+const (
+	// ExistingSkip leaves existing tests as is and does not generate them again.
+	ExistingSkip ExistingPolicy = iota
+	// ExistingUpdate regenerates existing tests keeping their test cases, i.e.
+	// the tests := []test{...} rows, and statements that were not generated.
+	ExistingUpdate
+	// ExistingForce regenerates existing tests along with their test cases. Tests
+	// are replaced as a whole, only their doc comments are kept.
+	ExistingForce
+)
+
+// replacement a test to be replaced with the newly generated one after the rendering.
+// Only generated parts of the existing test are replaced unless it is forced.
+type replacement struct {
+	file  string
+	name  string
+	call  string
+	force bool
+}
+
+// testExists checks if there is a function with the given name in the test file.
+func (g *Generator) testExists(testFile, name string) (bool, error) {
+	tests, ok := g.existingTests[testFile]
+	if !ok {
+		tests = map[string]struct{}{}
+		g.existingTests[testFile] = tests
+
+		src, err := g.readFile(filepath.Join(g.pkgDir(), testFile))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return false, nil
+			}
+
+			return false, errors.Wrap(err, "read test file")
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), testFile, src, parser.SkipObjectResolution)
+		if err != nil {
+			return false, errors.Wrap(err, "parse test file")
+		}
+
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+				tests[fn.Name.Name] = struct{}{}
+			}
+		}
+	}
+
+	_, ok = tests[name]
+	return ok, nil
+}
+
+// replaceExisting replaces tests existed before with ones appended during the rendering.
+func (g *Generator) replaceExisting() error {
+	for _, rpl := range g.replacements {
+		name, err := g.outputPath(rpl.file)
+		if err != nil {
+			return errors.Wrap(err, "get rendered test file path")
+		}
+
+		if err := rpl.apply(name); err != nil {
+			return errors.Wrapf(err, "replace %s in %s", rpl.name, rpl.file)
+		}
+	}
+
+	return nil
+}
+
+// apply replaces generated parts of the test in the rendered test file with the given name
+// with ones of the newly generated test, which is removed from the end of the file.
+func (rpl replacement) apply(name string) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return errors.Wrap(err, "get test file info")
+	}
+
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return errors.Wrap(err, "read test file")
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return errors.Wrap(err, "parse test file")
+	}
+
+	var decls []*ast.FuncDecl
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == rpl.name {
+			decls = append(decls, fn)
+		}
+	}
+	if len(decls) != 2 {
+		return errors.Newf("exactly two functions %s expected after the rendering, got %d", rpl.name, len(decls))
+	}
+
+	sp := splicer{
+		fset: fset,
+		src:  src,
+		rpl:  rpl,
+	}
+	oldDecl, newDecl := decls[0], decls[1]
+	newStart, newEnd := sp.offset(newDecl.Pos()), sp.offset(newDecl.End())
+
+	// The old declaration is updated in place to keep its position and doc comment,
+	// the new one is removed from the end.
+	var buf bytes.Buffer
+	buf.Write(src[:sp.offset(oldDecl.Body.Lbrace)])
+	if rpl.force {
+		buf.Write(sp.text(newDecl.Body))
+	} else {
+		sp.body(&buf, oldDecl.Body, newDecl.Body)
+	}
+	buf.Write(src[sp.offset(oldDecl.End()):newStart])
+	buf.Write(src[newEnd:])
+
+	res, err := dropUnusedImports(buf.Bytes(), selectorNames(oldDecl))
+	if err != nil {
+		return errors.Wrap(err, "format updated test file")
+	}
+
+	if err := os.WriteFile(name, res, info.Mode().Perm()); err != nil {
+		return errors.Wrap(err, "write updated test file")
+	}
+
+	return nil
+}
+
+// splicer puts generated parts of the new test into the old one.
+type splicer struct {
+	fset *token.FileSet
+	src  []byte
+	rpl  replacement
+}
+
+func (sp splicer) offset(pos token.Pos) int {
+	return sp.fset.Position(pos).Offset
+}
+
+func (sp splicer) text(node ast.Node) []byte {
+	return sp.src[sp.offset(node.Pos()):sp.offset(node.End())]
+}
+
+// body writes the old test body with generated statements replaced with new ones. Generated
+// statements missing in the old body are put before the next generated statement there and
+// ones not generated anymore are removed.
+func (sp splicer) body(buf *bytes.Buffer, oldBody, newBody *ast.BlockStmt) {
+	newStmts := map[string]ast.Stmt{}
+	before := map[string][]ast.Stmt{}
+	oldKeys := map[string]struct{}{}
+	for _, stmt := range oldBody.List {
+		if key := generatedStmtKey(stmt); key != "" {
+			oldKeys[key] = struct{}{}
+		}
+	}
+
+	var pending []ast.Stmt
+	for _, stmt := range newBody.List {
+		key := generatedStmtKey(stmt)
+		if key == "" {
+			continue
+		}
+
+		newStmts[key] = stmt
+		if _, ok := oldKeys[key]; !ok {
+			pending = append(pending, stmt)
+			continue
+		}
+
+		before[key] = pending
+		pending = nil
+	}
+
+	pos := sp.offset(oldBody.Lbrace)
+	for _, stmt := range oldBody.List {
+		key := generatedStmtKey(stmt)
+		if key == "" {
+			continue
+		}
+
+		start := sp.offset(stmt.Pos())
+		buf.Write(sp.src[pos:start])
+		pos = sp.offset(stmt.End())
+
+		for _, ns := range before[key] {
+			buf.Write(sp.text(ns))
+			buf.WriteString("\n")
+		}
+
+		if ns, ok := newStmts[key]; ok {
+			sp.stmt(buf, key, stmt, ns)
+		}
+	}
+
+	end := sp.offset(oldBody.Rbrace)
+	buf.Write(sp.src[pos:end])
+	for _, ns := range pending {
+		buf.Write(sp.text(ns))
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}")
+}
+
+// stmt writes the generated statement in place of the old one.
+func (sp splicer) stmt(buf *bytes.Buffer, key string, oldStmt, newStmt ast.Stmt) {
+	switch key {
+	case "tests":
+		if testRows(oldStmt) == nil {
+			message.Warningf("%s no test cases found in the existing test", sp.fset.Position(oldStmt.Pos()))
+			break
+		}
+
+		buf.Write(sp.text(oldStmt))
+		return
+
+	case "loop":
+		oldRun, newRun := subtestBody(oldStmt), subtestBody(newStmt)
+		if oldRun == nil || newRun == nil {
+			break
+		}
+
+		buf.Write(sp.src[sp.offset(newStmt.Pos()):sp.offset(newRun.Lbrace)])
+		sp.subtest(buf, oldRun, newRun)
+		buf.Write(sp.src[sp.offset(newRun.End()):sp.offset(newStmt.End())])
+		return
+	}
+
+	buf.Write(sp.text(newStmt))
+}
+
+// subtest writes the subtest body keeping the old test setup and replacing the call of
+// the tested function and checks of its results. Setup statements missing in the old
+// body are put before the call.
+func (sp splicer) subtest(buf *bytes.Buffer, oldBody, newBody *ast.BlockStmt) {
+	oldCall := sp.callIndex(oldBody)
+	newCall := sp.callIndex(newBody)
+	if oldCall < 0 || newCall < 0 {
+		buf.Write(sp.text(newBody))
+		return
+	}
+
+	buf.Write(sp.src[sp.offset(oldBody.Lbrace):sp.offset(oldBody.List[oldCall].Pos())])
+	for _, stmt := range newBody.List[:newCall] {
+		if !sp.hasStmt(oldBody.List[:oldCall], stmt) {
+			buf.Write(sp.text(stmt))
+			buf.WriteString("\n")
+		}
+	}
+	buf.Write(sp.src[sp.offset(newBody.List[newCall].Pos()):sp.offset(newBody.End())])
+}
+
+// callIndex returns an index of the statement calling the tested function, -1 if there is none.
+func (sp splicer) callIndex(body *ast.BlockStmt) int {
+	for i, stmt := range body.List {
+		found := false
+		ast.Inspect(stmt, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || found {
+				return !found
+			}
+
+			fun := call.Fun
+			switch v := fun.(type) {
+			case *ast.IndexExpr:
+				fun = v.X
+			case *ast.IndexListExpr:
+				fun = v.X
+			}
+
+			switch v := fun.(type) {
+			case *ast.Ident:
+				found = v.Name == sp.rpl.call
+			case *ast.SelectorExpr:
+				found = v.Sel.Name == sp.rpl.call
+			}
+
+			return !found
+		})
+		if found {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// hasStmt checks if there is the same statement or the one declaring the same variables among stmts.
+func (sp splicer) hasStmt(stmts []ast.Stmt, stmt ast.Stmt) bool {
+	names := declaredNames(stmt)
+	text := strings.Join(strings.Fields(string(sp.text(stmt))), " ")
+	for _, s := range stmts {
+		if names != "" && declaredNames(s) == names {
+			return true
+		}
+
+		if strings.Join(strings.Fields(string(sp.text(s))), " ") == text {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generatedStmtKey returns a key of the statement if it is a generated one
+// on the top level of the test function, an empty string otherwise.
+func generatedStmtKey(stmt ast.Stmt) string {
+	switch v := stmt.(type) {
+	case *ast.DeclStmt:
+		gd, ok := v.Decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE || len(gd.Specs) != 1 {
+			return ""
+		}
+
+		switch name := gd.Specs[0].(*ast.TypeSpec).Name.Name; name {
+		case "test", "argMocks":
+			return "type " + name
+		}
+	case *ast.AssignStmt:
+		if len(v.Lhs) == 1 && isIdent(v.Lhs[0], "tests") {
+			return "tests"
+		}
+	case *ast.RangeStmt:
+		if isIdent(v.X, "tests") {
+			return "loop"
+		}
+	case *ast.ExprStmt:
+		if call, ok := v.X.(*ast.CallExpr); ok && len(call.Args) == 0 {
+			if sel, ok := call.Fun.(*ast.SelectorExpr); ok && isIdent(sel.X, "t") && sel.Sel.Name == "Parallel" {
+				return "parallel"
+			}
+		}
+	}
+
+	return ""
+}
+
+// subtestBody returns a body of the t.Run function literal in the loop over test cases.
+func subtestBody(stmt ast.Stmt) *ast.BlockStmt {
+	loop := stmt.(*ast.RangeStmt)
+	for _, s := range loop.Body.List {
+		es, ok := s.(*ast.ExprStmt)
+		if !ok {
+			continue
+		}
+
+		call, ok := es.X.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			continue
+		}
+
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || !isIdent(sel.X, "t") || sel.Sel.Name != "Run" {
+			continue
+		}
+
+		if lit, ok := call.Args[1].(*ast.FuncLit); ok {
+			return lit.Body
+		}
+	}
+
+	return nil
+}
+
+// declaredNames returns names of variables the statement declares joined with commas.
+func declaredNames(stmt ast.Stmt) string {
+	var names []string
+	switch v := stmt.(type) {
+	case *ast.AssignStmt:
+		if v.Tok != token.DEFINE {
+			return ""
+		}
+
+		for _, e := range v.Lhs {
+			if id, ok := e.(*ast.Ident); ok {
+				names = append(names, id.Name)
+			}
+		}
+	case *ast.DeclStmt:
+		gd, ok := v.Decl.(*ast.GenDecl)
+		if !ok {
+			return ""
+		}
+
+		for _, spec := range gd.Specs {
+			switch s := spec.(type) {
+			case *ast.ValueSpec:
+				for _, id := range s.Names {
+					names = append(names, id.Name)
+				}
+			case *ast.TypeSpec:
+				names = append(names, s.Name.Name)
+			}
+		}
+	}
+
+	return strings.Join(names, ",")
+}
+
+func isIdent(e ast.Expr, name string) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && id.Name == name
+}
+
+// selectorNames returns names of packages or variables selectors in the node refer to.
+func selectorNames(node ast.Node) map[string]struct{} {
+	res := map[string]struct{}{}
+	ast.Inspect(node, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				res[id.Name] = struct{}{}
+			}
+		}
+
+		return true
+	})
+
+	return res
+}
+
+// dropUnusedImports formats the source removing imports of packages which were referred
+// to by the replaced test and are not used anymore.
+func dropUnusedImports(src []byte, replaced map[string]struct{}) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse source")
+	}
+
+	used := map[string]struct{}{}
+	for _, decl := range file.Decls {
+		for name := range selectorNames(decl) {
+			used[name] = struct{}{}
+		}
+	}
+
+	for _, spec := range file.Imports {
+		ipath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unquote import path %s", spec.Path.Value)
+		}
+
+		var alias string
+		name := importName(ipath)
+		if spec.Name != nil {
+			alias = spec.Name.Name
+			name = alias
+		}
+
+		if _, ok := replaced[name]; !ok {
+			continue
+		}
+		if _, ok := used[name]; ok {
+			continue
+		}
+
+		astutil.DeleteNamedImport(fset, file, alias, ipath)
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, errors.Wrap(err, "format source")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// importName guesses a name of the package with the given import path.
+func importName(ipath string) string {
+	parts := strings.Split(ipath, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = parts[len(parts)-2]
+	}
+
+	if pos := strings.IndexByte(name, '.'); pos >= 0 {
+		name = name[:pos]
+	}
+
+	return strings.TrimPrefix(name, "go-")
+}
+
+// testRows looks for the tests := []test{...} composite literal in the statement.
+func testRows(stmt ast.Stmt) *ast.CompositeLit {
+	var res *ast.CompositeLit
+	ast.Inspect(stmt, func(node ast.Node) bool {
+		if res != nil {
+			return false
+		}
+
+		v, ok := node.(*ast.AssignStmt)
+		if !ok {
+			return true
+		}
+
+		if len(v.Lhs) != 1 || len(v.Rhs) != 1 {
+			return true
+		}
+
+		id, ok := v.Lhs[0].(*ast.Ident)
+		if !ok || id.Name != "tests" {
+			return true
+		}
+
+		if lit, ok := v.Rhs[0].(*ast.CompositeLit); ok {
+			res = lit
+		}
+
+		return false
+	})
+
+	return res
+}
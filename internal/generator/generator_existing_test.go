@@ -0,0 +1,274 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/testlog"
+)
+
+func TestReplacementApply(t *testing.T) {
+	const src = `package x
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestF checks F.
+func TestF(t *testing.T) {
+	type test struct {
+		name string
+		a    int
+	}
+
+	prefix := "xx"
+	tests := []test{
+		{
+			name: "user case",
+			a:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := newT(prefix)
+			got := x.F(tt.a)
+			if !reflect.DeepEqual(got, 1) {
+				t.Error("unexpected")
+			}
+		})
+	}
+}
+
+func TestG(t *testing.T) {}
+
+func TestF(t *testing.T) {
+	type test struct {
+		name string
+		a    int
+		b    string
+	}
+
+	tests := []test{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var x T
+			x.b = tt.b
+			got := x.F(tt.a)
+			if got != 0 {
+				t.Error("unexpected")
+			}
+		})
+	}
+}
+`
+
+	const updated = `package x
+
+import (
+	"testing"
+)
+
+// TestF checks F.
+func TestF(t *testing.T) {
+	type test struct {
+		name string
+		a    int
+		b    string
+	}
+
+	prefix := "xx"
+	tests := []test{
+		{
+			name: "user case",
+			a:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := newT(prefix)
+			x.b = tt.b
+			got := x.F(tt.a)
+			if got != 0 {
+				t.Error("unexpected")
+			}
+		})
+	}
+}
+
+func TestG(t *testing.T) {}
+`
+
+	const forced = `package x
+
+import (
+	"testing"
+)
+
+// TestF checks F.
+func TestF(t *testing.T) {
+	type test struct {
+		name string
+		a    int
+		b    string
+	}
+
+	tests := []test{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var x T
+			x.b = tt.b
+			got := x.F(tt.a)
+			if got != 0 {
+				t.Error("unexpected")
+			}
+		})
+	}
+}
+
+func TestG(t *testing.T) {}
+`
+
+	tests := []struct {
+		name  string
+		force bool
+		want  string
+	}{
+		{
+			name:  "update",
+			force: false,
+			want:  updated,
+		},
+		{
+			name:  "force",
+			force: true,
+			want:  forced,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "x_test.go")
+			if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+				testlog.Error(t, errors.Wrap(err, "write test file"))
+				return
+			}
+
+			rpl := replacement{
+				file:  file,
+				name:  "TestF",
+				call:  "F",
+				force: tt.force,
+			}
+			if err := rpl.apply(file); err != nil {
+				testlog.Error(t, errors.Wrap(err, "apply replacement"))
+				return
+			}
+
+			got, err := os.ReadFile(file)
+			if err != nil {
+				testlog.Error(t, errors.Wrap(err, "read updated file"))
+				return
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("unexpected result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateExisting(t *testing.T) {
+	// The test was generated when Reset took one more parameter and returned a value.
+	const src = `package existing
+
+import (
+	"testing"
+
+	"github.com/sirkon/deepequal"
+)
+
+// TestReset checks values are emptied.
+func TestReset(t *testing.T) {
+	type test struct {
+		name   string
+		values []string
+		keep   bool
+		want   int
+	}
+
+	empty := []string{""}
+	tests := []test{
+		{
+			name:   "single",
+			values: []string{"a"},
+		},
+		{
+			name:   "empty",
+			values: empty,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Reset(tt.values, tt.keep)
+			if !deepequal.Equal(tt.want, got) {
+				deepequal.SideBySide(t, "the return value index 0 (int)", tt.want, got)
+			}
+		})
+	}
+}
+`
+
+	tests := []struct {
+		name     string
+		policy   ExistingPolicy
+		want     []string
+		dontWant []string
+	}{
+		{
+			name:     "update",
+			policy:   ExistingUpdate,
+			want:     []string{"// TestReset checks values are emptied.", `empty := []string{""}`, `"single"`, "Reset(tt.values)"},
+			dontWant: []string{"deepequal", "keep", "want"},
+		},
+		{
+			name:     "force",
+			policy:   ExistingForce,
+			want:     []string{"// TestReset checks values are emptied.", "Reset(tt.values)"},
+			dontWant: []string{"deepequal", "keep", "want", "empty :=", `"single"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := generateOverlayChecked(
+				t,
+				"existing",
+				map[string]string{"existing_test.go": src},
+				[]Target{{Name: "Reset"}},
+				WithExisting(tt.policy),
+			)
+			got, ok := files["existing_test.go"]
+			if !ok {
+				t.Fatalf("existing_test.go expected to be changed, got %d other files", len(files))
+			}
+
+			if n := strings.Count(got, "func TestReset("); n != 1 {
+				t.Errorf("exactly one TestReset expected, got %d:\n%s", n, got)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("%q expected in the regenerated test:\n%s", w, got)
+				}
+			}
+			for _, w := range tt.dontWant {
+				if strings.Contains(got, w) {
+					t.Errorf("%q is not expected in the regenerated test:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package generator
+
+import (
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirkon/errors"
+)
+
+// testPackage returns a renderer of the package tests are put in. It is the
+// external _test package in the external tests mode.
+func (g *Generator) testPackage() (*goPackage, error) {
+	if g.external {
+		return g.m.Package(g.pkg.Name+"_test", g.path)
+	}
+
+	return g.m.Package("", g.path)
+}
+
+// typeOf renders a reference to the type in tests. The external test package shares the
+// import path with the package under test, so the renderer takes types of the latter for
+// local ones. They are qualified explicitly there.
+func (g *Generator) typeOf(r *goRenderer, t types.Type) string {
+	if !g.external {
+		return r.Type(t)
+	}
+
+	return types.TypeString(t, func(p *types.Package) string {
+		if p.Path() == g.path {
+			r.Imports().Add(g.path).Ref("tested")
+			return r.S("$tested")
+		}
+
+		// A made up type of the package gives the name it is imported with.
+		obj := types.NewTypeName(token.NoPos, p, "_", nil)
+		return strings.TrimSuffix(r.Type(types.NewNamed(obj, types.Typ[types.Int], nil)), "._")
+	})
+}
+
+// typeFunc returns typeOf bound to the renderer.
+func (g *Generator) typeFunc(r *goRenderer) func(types.Type) string {
+	return func(t types.Type) string {
+		return g.typeOf(r, t)
+	}
+}
+
+// newMock renders creation of the mock. Mocks of the package under test
+// are qualified with its name in the external test package.
+func (g *Generator) newMock(r *goRenderer, mock MockLookupResult, ctrl string) string {
+	expr := g.backend.NewMock(r, mock, ctrl)
+	if !g.external || mock.Named.Obj().Pkg().Path() != g.path {
+		return expr
+	}
+
+	r.Imports().Add(g.path).Ref("tested")
+	if rest, ok := strings.CutPrefix(expr, "&"); ok {
+		return "&" + r.S("$tested.") + rest
+	}
+
+	return r.S("$tested.") + expr
+}
+
+// testPackageName returns a name of the package tests are put in.
+func (g *Generator) testPackageName() string {
+	if g.external {
+		return g.pkg.Name + "_test"
+	}
+
+	return g.pkg.Name
+}
+
+// checkTestFile checks if the existing test file belongs to the package tests are put in.
+func (g *Generator) checkTestFile(testFile string) error {
+	src, err := g.readFile(filepath.Join(g.pkgDir(), testFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return errors.Wrap(err, "read test file")
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), testFile, src, parser.PackageClauseOnly)
+	if err != nil {
+		return errors.Wrap(err, "parse test file")
+	}
+
+	if name := g.testPackageName(); file.Name.Name != name {
+		return errors.Newf(
+			"%s belongs to package %s while tests are generated in package %s, choose another test file",
+			testFile,
+			file.Name.Name,
+			name,
+		)
+	}
+
+	return nil
+}
+
+// checkExternal checks if the target can be tested from the external test package,
+// which only has access to exported identifiers.
+func (g *Generator) checkExternal(t target) error {
+	if !t.obj.Exported() {
+		return errors.Newf("%s is not exported and cannot be tested from package %s", t.obj.Name(), g.testPackageName())
+	}
+
+	if t.recv != nil && !t.recv.Obj().Exported() {
+		return errors.Newf(
+			"receiver type %s is not exported and cannot be tested from package %s",
+			t.recv.Obj().Name(),
+			g.testPackageName(),
+		)
+	}
+
+	tuples := []struct {
+		kind  string
+		tuple *types.Tuple
+	}{
+		{kind: "parameter", tuple: t.sig.Params()},
+		{kind: "result", tuple: t.sig.Results()},
+	}
+	for _, tt := range tuples {
+		for i := 0; i < tt.tuple.Len(); i++ {
+			if tn := g.unexportedType(tt.tuple.At(i).Type()); tn != nil {
+				return errors.Newf(
+					"type of the %s %d refers to unexported %s and cannot be used in package %s",
+					tt.kind,
+					i+1,
+					tn.Name(),
+					g.testPackageName(),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// unexportedType looks for an unexported type of the package under test the type refers to.
+func (g *Generator) unexportedType(t types.Type) *types.TypeName {
+	switch v := types.Unalias(t).(type) {
+	case *types.Named:
+		if obj := v.Obj(); obj.Pkg() != nil && obj.Pkg().Path() == g.path && !obj.Exported() {
+			return obj
+		}
+
+		for i := 0; i < v.TypeArgs().Len(); i++ {
+			if tn := g.unexportedType(v.TypeArgs().At(i)); tn != nil {
+				return tn
+			}
+		}
+	case *types.Pointer:
+		return g.unexportedType(v.Elem())
+	case *types.Slice:
+		return g.unexportedType(v.Elem())
+	case *types.Array:
+		return g.unexportedType(v.Elem())
+	case *types.Chan:
+		return g.unexportedType(v.Elem())
+	case *types.Map:
+		if tn := g.unexportedType(v.Key()); tn != nil {
+			return tn
+		}
+
+		return g.unexportedType(v.Elem())
+	case *types.Signature:
+		for _, tuple := range []*types.Tuple{v.Params(), v.Results()} {
+			for i := 0; i < tuple.Len(); i++ {
+				if tn := g.unexportedType(tuple.At(i).Type()); tn != nil {
+					return tn
+				}
+			}
+		}
+	}
+
+	return nil
+}
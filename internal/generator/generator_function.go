@@ -2,7 +2,6 @@ package generator
 
 import (
 	"go/types"
-	"strings"
 
 	"github.com/sirkon/errors"
 )
@@ -18,39 +17,43 @@ func GenerateForFunction(
 	if err != nil {
 		return errors.Wrap(err, "init generator")
 	}
+	defer g.cleanup()
 
-	f := g.pkg.Types.Scope().Lookup(fn)
-	if f == nil {
-		return errors.Newf("function %s not found", fn)
+	f, err := g.lookupFunction(fn)
+	if err != nil {
+		return err
 	}
 
-	s, ok := f.Type().(*types.Signature)
-	if !ok {
-		return errors.Newf("%s is not a function", fn)
+	p, err := g.testPackage()
+	if err != nil {
+		return errors.Wrap(err, "set up the package renderer")
 	}
 
-	if s.Recv() != nil {
-		return errors.New("function must not be a method of any type")
+	if err := g.generateFor(p, f); err != nil {
+		return err
 	}
 
-	p, err := g.m.Package("", g.path)
-	if err != nil {
-		return errors.Wrap(err, "set up the package renderer")
+	if err := g.render(); err != nil {
+		return err
 	}
 
-	testFile := strings.TrimSuffix(g.digObjectFile(f), ".go") + "_test.go"
-	r, err := p.Reuse(testFile)
-	if err != nil {
-		return errors.Wrap(err, "prepare test file")
+	return nil
+}
+
+func (g *Generator) lookupFunction(fn string) (*types.Func, error) {
+	obj := g.pkg.Types.Scope().Lookup(fn)
+	if obj == nil {
+		return nil, errors.Newf("function %s not found", fn)
 	}
 
-	if err := g.generate(p, r, f); err != nil {
-		return errors.Wrap(err, "generate source code")
+	f, ok := obj.(*types.Func)
+	if !ok {
+		return nil, errors.Newf("%s is not a function", fn)
 	}
 
-	if err := g.m.Render(); err != nil {
-		return errors.Wrap(err, "render generated source code")
+	if f.Type().(*types.Signature).Recv() != nil {
+		return nil, errors.New("function must not be a method of any type")
 	}
 
-	return nil
+	return f, nil
 }
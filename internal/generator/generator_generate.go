@@ -0,0 +1,110 @@
+package generator
+
+import (
+	"fmt"
+	"go/types"
+
+	"github.com/sirkon/errors"
+)
+
+// Target a function or a method to generate a test for. It is either
+// given by its name or by a source position inside its declaration.
+type Target struct {
+	// Type is a receiver type name for methods, empty for functions.
+	Type string
+	// Name is a function or method name.
+	Name string
+
+	// File is a source file of the declaration, Type and Name are ignored if it is set.
+	File string
+	// Line is a line number in the File starting from 1.
+	Line int
+	// Column is a column number in the Line starting from 1, the whole
+	// line is matched if it is zero.
+	Column int
+}
+
+func (t Target) String() string {
+	switch {
+	case t.File != "" && t.Column > 0:
+		return fmt.Sprintf("%s:%d:%d", t.File, t.Line, t.Column)
+	case t.File != "":
+		return fmt.Sprintf("%s:%d", t.File, t.Line)
+	case t.Type == "":
+		return t.Name
+	default:
+		return t.Type + "." + t.Name
+	}
+}
+
+// Generate generates table tests for the given functions and methods of the package.
+// Everything is rendered at once.
+func Generate(
+	pkg string,
+	targets []Target,
+	mockLookup MockLookup,
+	msgsRenderer LoggingRenderer,
+	opts ...Option,
+) error {
+	g, err := newGenerator(pkg, mockLookup, msgsRenderer, opts...)
+	if err != nil {
+		return errors.Wrap(err, "init generator")
+	}
+	defer g.cleanup()
+
+	p, err := g.testPackage()
+	if err != nil {
+		return errors.Wrap(err, "set up the package renderer")
+	}
+
+	for _, t := range targets {
+		if err := g.ctx.Err(); err != nil {
+			return errors.Wrap(err, "generation interrupted")
+		}
+
+		f, err := g.lookupTarget(t)
+		if err != nil {
+			return errors.Wrapf(err, "look for %s", t)
+		}
+
+		if err := g.generateFor(p, f); err != nil {
+			return errors.Wrapf(err, "generate test for %s", t)
+		}
+	}
+
+	return g.render()
+}
+
+func (g *Generator) lookupTarget(t Target) (*types.Func, error) {
+	if t.File != "" {
+		return g.lookupPosition(t.File, t.Line, t.Column)
+	}
+
+	if t.Type == "" {
+		return g.lookupFunction(t.Name)
+	}
+
+	return g.lookupMethod(t.Type, t.Name)
+}
+
+// GenerateFiles works like Generate but returns files created or changed instead
+// of writing them. See WithDryRun.
+func GenerateFiles(
+	pkg string,
+	targets []Target,
+	mockLookup MockLookup,
+	msgsRenderer LoggingRenderer,
+	opts ...Option,
+) ([]RenderedFile, error) {
+	var res []RenderedFile
+	opts = append(opts, WithDryRun(func(files []RenderedFile) error {
+		res = files
+		return nil
+	}))
+
+	if err := Generate(pkg, targets, mockLookup, msgsRenderer, opts...); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
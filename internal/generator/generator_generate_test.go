@@ -0,0 +1,198 @@
+package generator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/gogh"
+	"github.com/sirkon/testlog"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestGenerateReceiverContext(t *testing.T) {
+	files := generateChecked(t, "ctxfield", []Target{{Type: "Worker", Name: "Name"}})
+	src := files["ctxfield_test.go"]
+	if !strings.Contains(src, "x.ctx = ctx") {
+		t.Errorf("receiver context field is not set:\n%s", src)
+	}
+	if strings.Contains(src, "gomock") {
+		t.Errorf("mocking library is not needed without mocks:\n%s", src)
+	}
+}
+
+func TestGeneratePointerReceiverFields(t *testing.T) {
+	files := generateChecked(
+		t,
+		"missingfield",
+		[]Target{{Type: "Counter", Name: "Write"}},
+		WithMissingMockPolicy(MissingMockValue),
+	)
+	src := files["missingfield_test.go"]
+	if !strings.Contains(src, "x := &Counter{}") {
+		t.Errorf("pointer receiver is not allocated before its fields are set:\n%s", src)
+	}
+	if !strings.Contains(src, "x.w = tt.w") {
+		t.Errorf("receiver field without mock is not set from test case:\n%s", src)
+	}
+}
+
+func TestGenerateTypeConstructor(t *testing.T) {
+	tests := []struct {
+		name   string
+		target Target
+		want   string
+	}{
+		{
+			name:   "all mocks are passed",
+			target: Target{Type: "Full", Name: "Write"},
+			want:   "return NewFull(m.",
+		},
+		{
+			name:   "constructor without parameters",
+			target: Target{Type: "Zero", Name: "Write"},
+			want:   "return &Zero{",
+		},
+		{
+			name:   "constructor taking a part of mocks",
+			target: Target{Type: "Partial", Name: "Copy"},
+			want:   "return &Partial{",
+		},
+		{
+			name:   "constructor returning an error",
+			target: Target{Type: "Checked", Name: "Write"},
+			want:   "return &Checked{",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := generateChecked(t, "constructor", []Target{tt.target})
+
+			var srcs []string
+			for _, src := range files {
+				srcs = append(srcs, src)
+			}
+			if src := strings.Join(srcs, "\n"); !strings.Contains(src, tt.want) {
+				t.Errorf("%q expected in the mocker:\n%s", tt.want, src)
+			}
+		})
+	}
+}
+
+func TestGenerateIterPairs(t *testing.T) {
+	files := generateChecked(t, "iters", []Target{{Name: "Groups"}})
+	src := files["iters_test.go"]
+	if !strings.Contains(src, "[]struct {") || strings.Contains(src, "maps.Collect") {
+		t.Errorf("iterator pairs are not collected into a slice:\n%s", src)
+	}
+}
+
+func TestGenerateExternal(t *testing.T) {
+	files := generateChecked(
+		t,
+		"external",
+		[]Target{{Name: "Shift"}, {Type: "Box", Name: "Add"}},
+		WithExternalTests,
+	)
+	src, ok := files["external_external_test.go"]
+	if !ok {
+		t.Fatalf("external_external_test.go expected, got %d other files", len(files))
+	}
+	if !strings.Contains(src, "package external_test") {
+		t.Errorf("tests are not in the external test package:\n%s", src)
+	}
+	if !strings.Contains(src, "external.Point") {
+		t.Errorf("types of the package under test are not qualified:\n%s", src)
+	}
+}
+
+// generateChecked generates tests for targets of the package in testdata and
+// type checks the package with them. Returns generated files by their names.
+func generateChecked(t *testing.T, pkg string, targets []Target, opts ...Option) map[string]string {
+	t.Helper()
+
+	return generateOverlayChecked(t, pkg, nil, targets, opts...)
+}
+
+// generateOverlayChecked does what generateChecked does with sources of the package
+// taken from the overlay, which is keyed by file names in the package directory.
+func generateOverlayChecked(
+	t *testing.T,
+	pkg string,
+	sources map[string]string,
+	targets []Target,
+	opts ...Option,
+) map[string]string {
+	t.Helper()
+
+	dir := "./" + filepath.ToSlash(filepath.Join("testdata", pkg))
+	overlay := map[string][]byte{}
+	for name, src := range sources {
+		file, err := filepath.Abs(filepath.Join(dir, name))
+		if err != nil {
+			testlog.Error(t, errors.Wrap(err, "get absolute path of the source"))
+			t.FailNow()
+		}
+
+		overlay[file] = []byte(src)
+	}
+	if len(overlay) > 0 {
+		opts = append(opts, WithOverlay(overlay))
+	}
+
+	files, err := GenerateFiles(dir, targets, StdMockLookup(nil, "Mock${type}", nil), testLogging{}, opts...)
+	if err != nil {
+		testlog.Error(t, errors.Wrap(err, "generate tests"))
+		t.FailNow()
+	}
+
+	res := map[string]string{}
+	for _, f := range files {
+		res[filepath.Base(f.Path)] = string(f.Content)
+		overlay[f.Path] = f.Content
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:    packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
+		Tests:   true,
+		Overlay: overlay,
+	}, dir)
+	if err != nil {
+		testlog.Error(t, errors.Wrap(err, "load package with generated tests"))
+		t.FailNow()
+	}
+
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		for _, err := range p.Errors {
+			t.Errorf("generated code does not type check: %s", err)
+		}
+	})
+	if t.Failed() {
+		for name, src := range res {
+			t.Logf("%s:\n%s", name, src)
+		}
+		t.FailNow()
+	}
+
+	return res
+}
+
+type testLogging struct{}
+
+func (testLogging) ExpectedError(r *gogh.GoRenderer[*gogh.Imports]) {
+	r.L(`t.Log(err)`)
+}
+
+func (testLogging) UnexpectedError(r *gogh.GoRenderer[*gogh.Imports]) {
+	r.L(`t.Error(err)`)
+}
+
+func (testLogging) ErrorWasExpected(r *gogh.GoRenderer[*gogh.Imports]) {
+	r.L(`t.Error("error was expected")`)
+}
+
+func (testLogging) InvalidError(r *gogh.GoRenderer[*gogh.Imports], errvar string) {
+	r.L(`t.Error($0)`, errvar)
+}
@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"go/types"
+	"go/version"
+)
+
+// goVersionAtLeast checks if the go directive of the module tests are generated
+// for is the given version or later. It is not when the version is unknown.
+func (g *Generator) goVersionAtLeast(v string) bool {
+	if g.pkg.Module == nil || g.pkg.Module.GoVersion == "" {
+		return false
+	}
+
+	return version.Compare("go"+g.pkg.Module.GoVersion, "go"+v) >= 0
+}
+
+// anyType returns a name of the empty interface type suitable for the module.
+func (g *Generator) anyType() string {
+	if g.goVersionAtLeast("1.18") {
+		return "any"
+	}
+
+	return "interface{}"
+}
+
+// defaultCtxInit renders the test context. It is the one of the testing variable since
+// Go 1.24, so the context is cancelled when the test ends.
+func (g *Generator) defaultCtxInit(r *goRenderer, c ContextInit) {
+	if g.goVersionAtLeast("1.24") {
+		r.L(`ctx := $0.Context()`, c.Test)
+		return
+	}
+
+	r.Imports().Add("context").Ref("ctx")
+	r.L(`ctx := $ctx.Background()`)
+}
+
+// iterCollector returns a function collecting values of the range-over-func iterator
+// type to compare them and the type of the collection. Empty strings are returned for
+// other types.
+func (g *Generator) iterCollector(r *goRenderer, t types.Type) (collect string, collection string) {
+	if !g.goVersionAtLeast("1.23") {
+		return "", ""
+	}
+
+	n, ok := types.Unalias(t).(*types.Named)
+	if !ok || n.Obj().Pkg() == nil || n.Obj().Pkg().Path() != "iter" {
+		return "", ""
+	}
+
+	targs := n.TypeArgs()
+	switch n.Obj().Name() {
+	case "Seq":
+		r.Imports().Add("slices").Ref("slices")
+		return r.S("$slices.Collect"), "[]" + g.typeOf(r, targs.At(0))
+	case "Seq2":
+		// Pairs are kept in a slice, so their order and duplicate keys are
+		// compared too and keys do not need to be comparable.
+		pair := "struct{Key " + g.typeOf(r, targs.At(0)) + "; Value " + g.typeOf(r, targs.At(1)) + "}"
+		collection = "[]" + pair
+		collect = "func(seq " + g.typeOf(r, t) + ") (res " + collection + ") {\n" +
+			"for k, v := range seq {\n" +
+			"res = append(res, " + pair + "{Key: k, Value: v})\n" +
+			"}\n" +
+			"return res\n" +
+			"}"
+		return collect, collection
+	}
+
+	return "", ""
+}
@@ -4,7 +4,10 @@ import (
 	"go/token"
 	"go/types"
 	"path"
+	"path/filepath"
+	"strings"
 
+	"github.com/sirkon/gogh"
 	"github.com/sirkon/message"
 )
 
@@ -13,6 +16,29 @@ func (g *Generator) digObjectFile(f types.Object) string {
 	return file
 }
 
+// mockerTypeNames returns file and type names of the mocker for the target receiver.
+// Mockers of generic types' instances get type arguments into their names.
+func (g *Generator) mockerTypeNames(t target) (filename string, typename string) {
+	filename, typename = g.mockerNames(t.recv.Obj())
+	filename = strings.TrimSuffix(filename, ".go")
+	if len(t.targs) == 0 {
+		return filename + ".go", typename
+	}
+
+	words := t.instanceWords()
+	typename += gogh.Public(words...)
+	if strings.HasSuffix(filename, "_test") {
+		return strings.TrimSuffix(filename, "_test") + "_" + gogh.Underscored(words...) + "_test.go", typename
+	}
+
+	return filename + "_" + gogh.Underscored(words...) + ".go", typename
+}
+
+// pkgDir returns the directory of the package tests are generated for.
+func (g *Generator) pkgDir() string {
+	return filepath.Dir(g.pkg.GoFiles[0])
+}
+
 func (g *Generator) shouldNotBeMocked(vn *types.Named) bool {
 	if vn.Obj().Pkg() == nil {
 		return true
@@ -31,6 +57,10 @@ func (g *Generator) infoParamNotInterfaceOmit(pos token.Pos, name string) {
 	message.Debugf("%s type of parameter %s is not an interface, omitting", g.fset.Position(pos), name)
 }
 
+func (g *Generator) infoParamVariadicOmit(pos token.Pos, name string) {
+	message.Debugf("%s parameter %s is variadic, its values are set in test cases", g.fset.Position(pos), name)
+}
+
 func (g *Generator) infoFieldNotInterfaceOmit(pos token.Pos, name string) {
 	message.Debugf("%s type of field %s is not an interface, omitting", g.fset.Position(pos), name)
 }
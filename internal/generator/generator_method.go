@@ -2,7 +2,6 @@ package generator
 
 import (
 	"go/types"
-	"strings"
 
 	"github.com/sirkon/errors"
 )
@@ -19,44 +18,50 @@ func GenerateForMethod(
 	if err != nil {
 		return errors.Wrap(err, "init generator")
 	}
+	defer g.cleanup()
 
-	t := g.pkg.Types.Scope().Lookup(typ)
-	if t == nil {
-		return errors.Newf("type %s not found", typ)
+	f, err := g.lookupMethod(typ, method)
+	if err != nil {
+		return err
 	}
 
-	nd := t.Type().(*types.Named)
-	var f *types.Func
-	for i := 0; i < nd.NumMethods(); i++ {
-		ff := nd.Method(i)
-		if ff.Name() == method {
-			f = ff
-			break
-		}
+	p, err := g.testPackage()
+	if err != nil {
+		return errors.Wrap(err, "set up the package renderer")
 	}
 
-	if f == nil {
-		return errors.Newf("no method %s found for the type %s", method, typ)
+	if err := g.generateFor(p, f); err != nil {
+		return err
 	}
 
-	p, err := g.m.Package("", g.path)
-	if err != nil {
-		return errors.Wrap(err, "set up the package renderer")
+	if err := g.render(); err != nil {
+		return err
 	}
 
-	testFile := strings.TrimSuffix(g.digObjectFile(f), ".go") + "_test.go"
-	r, err := p.Reuse(testFile)
-	if err != nil {
-		return errors.Wrap(err, "prepare test file")
+	return nil
+}
+
+func (g *Generator) lookupMethod(typ, method string) (*types.Func, error) {
+	t := g.pkg.Types.Scope().Lookup(typ)
+	if t == nil {
+		return nil, errors.Newf("type %s not found", typ)
 	}
 
-	if err := g.generate(p, r, f); err != nil {
-		return errors.Wrap(err, "generate source code")
+	nd, ok := t.Type().(*types.Named)
+	if !ok {
+		return nil, errors.Newf("%s is not a defined type", typ)
 	}
 
-	if err := g.m.Render(); err != nil {
-		return errors.Wrap(err, "render generated source code")
+	if underlyingTypeIs[*types.Interface](nd) {
+		return nil, errors.Newf("%s is an interface, its methods have no implementation to test", typ)
 	}
 
-	return nil
+	for i := 0; i < nd.NumMethods(); i++ {
+		f := nd.Method(i)
+		if f.Name() == method {
+			return f, nil
+		}
+	}
+
+	return nil, errors.Newf("no method %s found for the type %s", method, typ)
 }
@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/message"
+)
+
+// MissingMockPolicy defines what to do with parameters and fields no mock was found for.
+type MissingMockPolicy int
+
+const (
+	// MissingMockFail fails the generation. This is the default.
+	MissingMockFail MissingMockPolicy = iota
+	// MissingMockValue treats the interface as a plain value, it is taken from
+	// the test structure field then, so users supply an implementation per row.
+	MissingMockValue
+	// MissingMockTODO leaves a TODO placeholder where the mock was supposed to be.
+	MissingMockTODO
+)
+
+// missingMock a parameter or a field of the receiver type no mock was found for.
+type missingMock struct {
+	name string
+	typ  *types.Named
+	pos  token.Pos
+
+	// path is a chain of fields from the receiver type down to the one
+	// with the missing mock. It is empty for parameters.
+	path []*types.Var
+
+	// field is a test structure field the value is taken from. It is set
+	// during the rendering of the test structure.
+	field string
+}
+
+// degrade checks if the mock lookup error can be worked around.
+func (g *Generator) degrade(err error) bool {
+	return g.missingMocks != MissingMockFail && errors.Is(err, ErrorMockNotFound)
+}
+
+// fieldPath returns a selector of the field from the receiver.
+func (m missingMock) fieldPath() string {
+	var fpath []string
+	for _, f := range m.path {
+		fpath = append(fpath, f.Name())
+	}
+
+	return strings.Join(fpath, ".")
+}
+
+// accessible checks if the field can be assigned in tests of the package.
+func (m missingMock) accessible(pkgPath string) bool {
+	for _, f := range m.path {
+		if !f.Exported() && f.Pkg().Path() != pkgPath {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setsFields checks if any of receiver fields is set from test cases.
+func setsFields(missing []missingMock) bool {
+	for _, m := range missing {
+		if len(m.path) > 0 && m.field != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findMissingParam looks for a parameter with the given name among missing mocks.
+func findMissingParam(missing []missingMock, name string) (missingMock, bool) {
+	for _, m := range missing {
+		if len(m.path) == 0 && m.name == name {
+			return m, true
+		}
+	}
+
+	return missingMock{}, false
+}
+
+// recordMissingMocks keeps missing mocks of the generated test to report them at the end.
+func (g *Generator) recordMissingMocks(t target, missing []missingMock) {
+	for _, m := range missing {
+		what := "parameter " + m.name
+		if len(m.path) > 0 {
+			what = "field " + m.fieldPath()
+		}
+
+		how := "left as TODO"
+		if g.missingMocks == MissingMockValue {
+			how = "taken from test rows"
+		}
+
+		g.degraded = append(g.degraded, g.fset.Position(m.pos).String()+" Test"+t.name()+": no mock for "+
+			m.typ.String()+" of "+what+", "+how)
+	}
+}
+
+// reportMissingMocks prints a summary of parameters and fields left without mocks.
+func (g *Generator) reportMissingMocks() {
+	if len(g.degraded) == 0 {
+		return
+	}
+
+	message.Warningf("%d parameters and fields were left without mocks:", len(g.degraded))
+	for _, d := range g.degraded {
+		message.Warning("    " + d)
+	}
+}
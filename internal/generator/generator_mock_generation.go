@@ -0,0 +1,382 @@
+package generator
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/gogh"
+	"github.com/sirkon/message"
+)
+
+// mockGeneration settings of missing mocks generation.
+type mockGeneration struct {
+	// path is a package path relative to the module root to put mocks in,
+	// they are put next to tests if it is empty.
+	path string
+	// template is a mock type name template.
+	template string
+}
+
+// lookupMock looks for a mock of the given type and generates it if none
+// was found and mocks generation is enabled.
+func (g *Generator) lookupMock(t *types.Named) (MockLookupResult, error) {
+	if res, ok := g.mocks[t]; ok {
+		return res, nil
+	}
+
+	res, err := g.findMock(t)
+	if err != nil {
+		if g.mockGen == nil || !errors.Is(err, ErrorMockNotFound) {
+			return res, err
+		}
+
+		res, err = g.generateMock(t)
+		if err != nil {
+			return res, errors.Wrap(err, "generate missing mock")
+		}
+	}
+
+	// Custom lookups may not ask the backend for constructors, so the gomock
+	// package is detected with every mock found.
+	if backend, ok := g.backend.(*gomockBackend); ok && res.Constructor != nil {
+		if err := backend.detect(res.Constructor); err != nil {
+			return res, errors.Wrapf(err, "check mock %s constructor", res.Named)
+		}
+	}
+
+	g.mocks[t] = res
+	return res, nil
+}
+
+// findMock looks for an existing mock of t with the lookup, in packages of mock
+// paths if there is none in its own one.
+func (g *Generator) findMock(t *types.Named) (MockLookupResult, error) {
+	res, err := g.mockLookup(g, t)
+	for _, path := range g.mockPaths {
+		if err == nil || !errors.Is(err, ErrorMockNotFound) {
+			break
+		}
+
+		res, err = g.mockLookup(mockPathProvider{
+			Generator: g,
+			from:      t.Obj().Pkg().Path(),
+			to:        path,
+		}, t)
+	}
+
+	return res, err
+}
+
+// generateMock generates gomock mock for the given interface type.
+func (g *Generator) generateMock(t *types.Named) (res MockLookupResult, _ error) {
+	backend, ok := g.backend.(*gomockBackend)
+	if !ok {
+		return res, errors.Newf("mocks generation is not supported for %s mocks", g.backend.Name())
+	}
+
+	if t.TypeParams().Len() > 0 || t.TypeArgs().Len() > 0 {
+		return res, errors.Newf("mocks generation is not supported for generic interfaces")
+	}
+
+	iface := t.Underlying().(*types.Interface)
+	for i := 0; i < iface.NumMethods(); i++ {
+		if !iface.Method(i).Exported() && t.Obj().Pkg().Path() != g.path {
+			return res, errors.Newf("%s has unexported methods and cannot be mocked outside of its package", t)
+		}
+	}
+
+	mockName := formatMockName(g.mockGen.template, t)
+
+	var tpkg *types.Package
+	var pkgpath string
+	var dir string
+	var filename string
+	// The mocks package would import the one tests are generated for, which
+	// cannot be imported back by its tests then. Its mocks are put next to tests.
+	if g.mockGen.path == "" || t.Obj().Pkg().Path() == g.path {
+		tpkg = g.pkg.Types
+		pkgpath = g.path
+		dir = g.pkgDir()
+		filename = gogh.Underscored(t.Obj().Name(), "mock", "test") + ".go"
+	} else {
+		pkgpath = path.Join(g.m.Name(), g.mockGen.path)
+		pkg, err := g.mocksPackage(pkgpath)
+		if err != nil {
+			return res, err
+		}
+		if res, err := mockLookup(pkg, t, mockName, backend); err == nil {
+			// It was generated before, the lookup just does not look there.
+			return res, nil
+		}
+
+		tpkg = pkg
+		dir = filepath.Join(g.pkg.Module.Dir, filepath.FromSlash(g.mockGen.path))
+		filename = gogh.Underscored(t.Obj().Name(), "mock") + ".go"
+	}
+
+	file := filepath.Join(dir, filename)
+	exists, err := g.declaresMock(file, mockName)
+	if err != nil {
+		return res, errors.Wrapf(err, "check %s", file)
+	}
+
+	if exists {
+		// Test files are not loaded, so a mock generated next to tests before
+		// is never found by lookups. It is reused as is.
+		message.Debugf("reusing mock %s for %s in %s", mockName, t, file)
+	} else {
+		var p *goPackage
+		if pkgpath == g.path {
+			p, err = g.m.Package("", pkgpath)
+		} else {
+			p, err = g.m.Package(tpkg.Name(), pkgpath)
+		}
+		if err != nil {
+			return res, errors.Wrap(err, "set up the mocks package renderer")
+		}
+
+		r := p.Go(filename)
+		r.Let("any", g.anyType())
+		renderGomockMock(r, backend.gomockPath(), t, iface, mockName)
+
+		g.generatedMocks = append(g.generatedMocks, mockName+" for "+t.String()+" in "+path.Join(pkgpath, filename))
+	}
+
+	// Mock types do not exist yet, they are made up for the generation.
+	mockObj := types.NewTypeName(token.NoPos, tpkg, mockName, nil)
+	mockStruct := types.NewStruct(nil, nil)
+	mockNamed := types.NewNamed(mockObj, mockStruct, nil)
+
+	ctrlPkg := types.NewPackage(backend.gomockPath(), "gomock")
+	ctrlObj := types.NewTypeName(token.NoPos, ctrlPkg, gomockController, nil)
+	ctrlNamed := types.NewNamed(ctrlObj, types.NewStruct(nil, nil), nil)
+
+	constr := types.NewFunc(
+		token.NoPos,
+		tpkg,
+		"New"+mockName,
+		types.NewSignatureType(
+			nil,
+			nil,
+			nil,
+			types.NewTuple(types.NewVar(token.NoPos, tpkg, "ctrl", types.NewPointer(ctrlNamed))),
+			types.NewTuple(types.NewVar(token.NoPos, tpkg, "", types.NewPointer(mockNamed))),
+			false,
+		),
+	)
+
+	res.Named = mockNamed
+	res.Type = mockStruct
+	res.Constructor = constr
+
+	return res, nil
+}
+
+// mocksPackage returns the package to put generated mocks in. The package is made
+// up if it does not exist yet, its name is taken from the path then.
+func (g *Generator) mocksPackage(pkgpath string) (*types.Package, error) {
+	if pkg, err := g.loadPackage(pkgpath); err == nil {
+		return pkg, nil
+	}
+
+	name := path.Base(pkgpath)
+	if isMajorVersion(name) {
+		name = path.Base(path.Dir(pkgpath))
+	}
+	if !token.IsIdentifier(name) {
+		return nil, errors.Newf(
+			"%s is not a valid package name, create the package %s to put mocks in first",
+			name,
+			pkgpath,
+		)
+	}
+
+	return types.NewPackage(pkgpath, name), nil
+}
+
+// isMajorVersion checks if the path element is a major version suffix like v2.
+func isMajorVersion(v string) bool {
+	num, ok := strings.CutPrefix(v, "v")
+	if !ok || num == "" {
+		return false
+	}
+
+	_, err := strconv.Atoi(num)
+	return err == nil
+}
+
+// declaresMock checks if the file declares the mock and its constructor. Missing file
+// does not declare anything, the file cannot be taken if it has no mock though.
+func (g *Generator) declaresMock(file string, mockName string) (bool, error) {
+	data, err := g.readFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+
+		return false, errors.Wrap(err, "read file")
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), file, data, parser.SkipObjectResolution)
+	if err != nil {
+		return false, errors.Wrap(err, "parse file")
+	}
+
+	var typ, constr bool
+	for _, decl := range f.Decls {
+		switch v := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range v.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == mockName {
+					typ = true
+				}
+			}
+		case *ast.FuncDecl:
+			if v.Recv == nil && v.Name.Name == "New"+mockName {
+				constr = true
+			}
+		}
+	}
+	if !typ || !constr {
+		return false, errors.Newf("the file exists already and has no mock %s", mockName)
+	}
+
+	return true, nil
+}
+
+// renderGomockMock renders a mock in the form mockgen does.
+func renderGomockMock(r *goRenderer, gomock string, t *types.Named, iface *types.Interface, mockName string) {
+	r.Imports().Add(gomock).Ref("gomock")
+	r.Imports().Add("reflect").Ref("reflect")
+	r.Let("mock", mockName)
+	r.Let("recorder", mockName+"MockRecorder")
+
+	r.L(`// ${mock} is a mock of $0 interface.`, t.Obj().Name())
+	r.L(`type ${mock} struct {`)
+	r.L(`    ctrl     *$gomock.Controller`)
+	r.L(`    recorder *${recorder}`)
+	r.L(`}`)
+	r.N()
+	r.L(`// ${recorder} is the mock recorder for ${mock}.`)
+	r.L(`type ${recorder} struct {`)
+	r.L(`    mock *${mock}`)
+	r.L(`}`)
+	r.N()
+	r.L(`// New${mock} creates a new mock instance.`)
+	r.L(`func New${mock}(ctrl *$gomock.Controller) *${mock} {`)
+	r.L(`    mock := &${mock}{ctrl: ctrl}`)
+	r.L(`    mock.recorder = &${recorder}{mock}`)
+	r.L(`    return mock`)
+	r.L(`}`)
+	r.N()
+	r.L(`// EXPECT returns an object that allows the caller to indicate expected use.`)
+	r.L(`func (m *${mock}) EXPECT() *${recorder} {`)
+	r.L(`    return m.recorder`)
+	r.L(`}`)
+
+	for i := 0; i < iface.NumMethods(); i++ {
+		method := iface.Method(i)
+		s := method.Type().(*types.Signature)
+
+		params := &gogh.Params{}
+		recParams := &gogh.Params{}
+		var args []string
+		for j := 0; j < s.Params().Len(); j++ {
+			name := "a" + strconv.Itoa(j)
+			args = append(args, name)
+
+			if s.Variadic() && j == s.Params().Len()-1 {
+				params.Add(name, "..."+r.Type(s.Params().At(j).Type().(*types.Slice).Elem()))
+				recParams.Add(name, r.S(`...${any}`))
+				continue
+			}
+
+			params.Add(name, r.Type(s.Params().At(j).Type()))
+			recParams.Add(name, r.S(`${any}`))
+		}
+
+		var results []string
+		for j := 0; j < s.Results().Len(); j++ {
+			results = append(results, r.Type(s.Results().At(j).Type()))
+		}
+
+		r.N()
+		r.L(`// $0 mocks base method.`, method.Name())
+		switch len(results) {
+		case 0:
+			r.L(`func (m *${mock}) $0($1) {`, method.Name(), params)
+		case 1:
+			r.L(`func (m *${mock}) $0($1) $2 {`, method.Name(), params, results[0])
+		default:
+			r.L(`func (m *${mock}) $0($1) ($2) {`, method.Name(), params, strings.Join(results, ", "))
+		}
+		r.L(`    m.ctrl.T.Helper()`)
+		callArgs := strings.Join(args, ", ")
+		if s.Variadic() {
+			last := args[len(args)-1]
+			r.L(`    varargs := []${any}{$0}`, strings.Join(args[:len(args)-1], ", "))
+			r.L(`    for _, a := range $0 {`, last)
+			r.L(`        varargs = append(varargs, a)`)
+			r.L(`    }`)
+			callArgs = "varargs..."
+		}
+		var callPrefix string
+		if len(results) > 0 {
+			callPrefix = "ret := "
+		}
+		if callArgs == "" {
+			r.L(`    $0m.ctrl.Call(m, "$1")`, callPrefix, method.Name())
+		} else {
+			r.L(`    $0m.ctrl.Call(m, "$1", $2)`, callPrefix, method.Name(), callArgs)
+		}
+		if len(results) > 0 {
+			var rets []string
+			for j, res := range results {
+				ret := "ret" + strconv.Itoa(j)
+				rets = append(rets, ret)
+				r.L(`    $0, _ := ret[$1].($2)`, ret, j, res)
+			}
+			r.L(`    return $0`, strings.Join(rets, ", "))
+		}
+		r.L(`}`)
+
+		r.N()
+		r.L(`// $0 indicates an expected call of $0.`, method.Name())
+		r.L(`func (mr *${recorder}) $0($1) *$gomock.Call {`, method.Name(), recParams)
+		r.L(`    mr.mock.ctrl.T.Helper()`)
+		recArgs := strings.Join(args, ", ")
+		if s.Variadic() {
+			last := args[len(args)-1]
+			r.L(`    varargs := append([]${any}{$0}, $1...)`, strings.Join(args[:len(args)-1], ", "), last)
+			recArgs = "varargs..."
+		}
+		if recArgs == "" {
+			r.L(
+				`    return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "$0", $reflect.TypeOf((*${mock})(nil).$0))`,
+				method.Name(),
+			)
+		} else {
+			r.L(
+				`    return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "$0", $reflect.TypeOf((*${mock})(nil).$0), $1)`,
+				method.Name(),
+				recArgs,
+			)
+		}
+		r.L(`}`)
+	}
+}
+
+// reportGeneratedMocks prints mocks generated during this run.
+func (g *Generator) reportGeneratedMocks() {
+	for _, mock := range g.generatedMocks {
+		message.Infof("generated mock %s", mock)
+	}
+}
@@ -0,0 +1,146 @@
+package generator
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/testlog"
+)
+
+func TestGenerateMissingMocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  Target
+		path    string
+		file    string
+		pkgname string
+		golden  string
+	}{
+		{
+			name:    "own interface next to tests",
+			target:  Target{Type: "Cache", Name: "Get"},
+			path:    "internal/generator/testdata/mockgen/mocks",
+			file:    "store_mock_test.go",
+			pkgname: "mockgen",
+			golden:  "store_mock_test.golden",
+		},
+		{
+			name:    "mocks package with major version",
+			target:  Target{Name: "Dump"},
+			path:    "internal/generator/testdata/mockgen/mocks/v2",
+			file:    "writer_mock.go",
+			pkgname: "mocks",
+			golden:  "writer_mock.golden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := generateChecked(t, "mockgen", []Target{tt.target}, WithMockGeneration(tt.path, ""))
+			src, ok := files[tt.file]
+			if !ok {
+				t.Fatalf("mock file %s expected among %d generated", tt.file, len(files))
+			}
+
+			checkGolden(t, filepath.Join("testdata", "mockgen", tt.golden), tt.pkgname, src)
+		})
+	}
+}
+
+func TestGenerateMissingMocksReuse(t *testing.T) {
+	files, err := GenerateFiles(
+		"./testdata/mockgen",
+		[]Target{{Type: "Cache", Name: "Get"}},
+		StdMockLookup(nil, "Mock${type}", nil),
+		testLogging{},
+		WithMockGeneration("", ""),
+	)
+	if err != nil {
+		testlog.Error(t, errors.Wrap(err, "generate tests"))
+		return
+	}
+
+	// The mock is edited, so it would show up as a change if it was rendered again.
+	overlay := map[string][]byte{}
+	for _, f := range files {
+		if filepath.Base(f.Path) == "store_mock_test.go" {
+			overlay[f.Path] = append(f.Content, []byte("\n// Edited.\n")...)
+		}
+	}
+	if len(overlay) == 0 {
+		t.Fatal("mock was not generated")
+	}
+
+	files, err = GenerateFiles(
+		"./testdata/mockgen",
+		[]Target{{Type: "Cache", Name: "Get"}},
+		StdMockLookup(nil, "Mock${type}", nil),
+		testLogging{},
+		WithMockGeneration("", ""),
+		WithOverlay(overlay),
+	)
+	if err != nil {
+		testlog.Error(t, errors.Wrap(err, "generate tests again"))
+		return
+	}
+
+	for _, f := range files {
+		if filepath.Base(f.Path) == "store_mock_test.go" {
+			t.Errorf("mock generated before was rendered again:\n%s", f.Content)
+		}
+	}
+}
+
+func TestGenerateMissingMocksInvalidPackage(t *testing.T) {
+	_, err := GenerateFiles(
+		"./testdata/mockgen",
+		[]Target{{Name: "Dump"}},
+		StdMockLookup(nil, "Mock${type}", nil),
+		testLogging{},
+		WithMockGeneration("internal/generator/testdata/mockgen/mock-s", ""),
+	)
+	if err == nil {
+		t.Error("error was expected for the package path without a valid name")
+		return
+	}
+
+	testlog.Log(t, errors.Wrap(err, "expected error"))
+}
+
+// checkGolden checks the package name of the source and compares its
+// declarations after imports with the golden file.
+func checkGolden(t *testing.T, golden string, pkgname string, src string) {
+	t.Helper()
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		testlog.Error(t, errors.Wrap(err, "read golden file"))
+		return
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly)
+	if err != nil {
+		testlog.Error(t, errors.Wrap(err, "parse generated source"))
+		return
+	}
+
+	if f.Name.Name != pkgname {
+		t.Errorf("unexpected package %s, want %s", f.Name.Name, pkgname)
+	}
+
+	// Only import declarations are parsed in this mode.
+	end := f.Name.End()
+	if len(f.Decls) > 0 {
+		end = f.Decls[len(f.Decls)-1].End()
+	}
+
+	got := strings.TrimSpace(src[end-f.FileStart:])
+	if got != strings.TrimSpace(string(want)) {
+		t.Errorf("generated code differs from %s:\n%s", golden, got)
+	}
+}
@@ -1,8 +1,12 @@
 package generator
 
 import (
+	"context"
 	"go/types"
+	"path/filepath"
+	"strings"
 
+	"github.com/sirkon/errors"
 	"github.com/sirkon/gogh"
 )
 
@@ -40,6 +44,160 @@ func WithMockContext(g *Generator, _ optionRestriction) error {
 	return nil
 }
 
+// WithEmbeddedStructs enables mocking of interface fields of embedded structures
+// in the receiver type. Only embedded interfaces are mocked by default.
+func WithEmbeddedStructs(g *Generator, _ optionRestriction) error {
+	g.embeddedStructs = true
+	return nil
+}
+
+// WithMockBackend sets mocking library backend. It is gomock by default.
+func WithMockBackend(backend MockBackend) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		g.backend = backend
+		if b, ok := backend.(*gomockBackend); ok {
+			// The gomock package is detected per generation, options can be
+			// reused for many of them.
+			fresh := *b
+			g.backend = &fresh
+		}
+
+		return nil
+	}
+}
+
+// WithMockLookup makes the generator try the lookup first. The lookup the generator
+// was created with is used if it returns ErrorMockNotFound.
+func WithMockLookup(lookup MockLookup) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		next := g.mockLookup
+		g.mockLookup = func(p PackageProvider, t *types.Named) (MockLookupResult, error) {
+			res, err := lookup(p, t)
+			if err == nil || !errors.Is(err, ErrorMockNotFound) {
+				return res, err
+			}
+
+			return next(p, t)
+		}
+		return nil
+	}
+}
+
+// WithMockPaths adds packages to look for mocks in when the mock lookup finds none.
+// The lookup is asked again as if these were packages of types mocked, so mock names
+// follow its template. Paths can be relative to the module root, they are loaded
+// along with the package tests are generated for.
+func WithMockPaths(paths ...string) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		g.mockPaths = append(g.mockPaths, paths...)
+		g.preload = append(g.preload, paths...)
+		return nil
+	}
+}
+
+// WithTestFile makes the generator put tests into the file with the given name in the
+// package directory instead of the _test.go file paired with the source file.
+func WithTestFile(name string) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		if filepath.Base(name) != name || !strings.HasSuffix(name, "_test.go") {
+			return errors.Newf("test file must be a _test.go file name in the package directory, got %q", name)
+		}
+
+		g.testFile = name
+		return nil
+	}
+}
+
+// WithExternalTests makes the generator put tests into the external _test package.
+// Only exported functions and methods can be tested there, receivers are left for
+// the user to set up as their fields are out of reach. Tests are put into the
+// _external_test.go file paired with the source file by default.
+func WithExternalTests(g *Generator, _ optionRestriction) error {
+	g.external = true
+	return nil
+}
+
+// WithDryRun makes the generator pass files it created or changed to the handler
+// instead of writing them. They are rendered into a scratch copy of the module.
+func WithDryRun(handler func(files []RenderedFile) error) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		g.dryRun = handler
+		return nil
+	}
+}
+
+// WithContext sets a context to load packages with and to stop the generation
+// when it is done.
+func WithContext(ctx context.Context) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		g.ctx = ctx
+		return nil
+	}
+}
+
+// WithCache makes the generator take loaded packages from the cache and put
+// them there.
+func WithCache(cache *Cache) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		g.cache = cache
+		return nil
+	}
+}
+
+// WithPreload adds packages to load along with the one tests are generated for,
+// like alternative mock packages. Paths can be relative to the module root.
+func WithPreload(paths ...string) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		g.preload = append(g.preload, paths...)
+		return nil
+	}
+}
+
+// WithSourceLoad makes the generator parse and type check sources of all
+// dependencies instead of using export data from the go build cache.
+func WithSourceLoad(g *Generator, _ optionRestriction) error {
+	g.sourceLoad = true
+	return nil
+}
+
+// WithOverlay sets contents of files that differ from ones on disk, e.g. unsaved editor
+// buffers. Keys are absolute file paths. They are used to load packages and to render
+// files in the dry run mode.
+func WithOverlay(overlay map[string][]byte) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		g.overlay = overlay
+		return nil
+	}
+}
+
+// WithMissingMockPolicy sets what to do with parameters and fields no mock was found for.
+func WithMissingMockPolicy(policy MissingMockPolicy) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		g.missingMocks = policy
+		return nil
+	}
+}
+
+// WithMockGeneration enables generation of gomock mocks that were not found.
+// They are put into the package with the given path relative to the module
+// root or next to the tests if the path is empty. Mocks of interfaces of the
+// package tests are generated for are always put next to the tests to avoid an
+// import cycle. The template defines mock type names the way StdMockLookup does,
+// it is "Mock${type}" if empty. Mocks generated before are reused.
+func WithMockGeneration(path, template string) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		if template == "" {
+			template = "Mock${type}"
+		}
+
+		g.mockGen = &mockGeneration{
+			path:     path,
+			template: template,
+		}
+		return nil
+	}
+}
+
 // WithMockerNames lets to set a file and type names for a mocker of a given type.
 func WithMockerNames(n func(tn *types.TypeName) (fileName string, typeName string)) Option {
 	return func(g *Generator, _ optionRestriction) error {
@@ -48,6 +206,49 @@ func WithMockerNames(n func(tn *types.TypeName) (fileName string, typeName strin
 	}
 }
 
+// WithMockerTemplates sets mocker file and type names with templates applied to the
+// type name the way StdMockLookup applies them, e.g. "${type|_}_mocker_test.go" and
+// "${type|p}Mocker". An empty template leaves the respective name as it was.
+func WithMockerTemplates(file, typ string) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		names := g.mockerNames
+		g.mockerNames = func(tn *types.TypeName) (fileName string, typeName string) {
+			fileName, typeName = names(tn)
+			if file != "" {
+				fileName = formatTypeName(file, tn.Name())
+			}
+			if typ != "" {
+				typeName = formatTypeName(typ, tn.Name())
+			}
+
+			return fileName, typeName
+		}
+		return nil
+	}
+}
+
+// WithInstance adds a set of type arguments to instantiate a generic function
+// or a generic receiver type with. A separate test is generated for each
+// instantiation.
+func WithInstance(typeArgs ...string) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		if len(typeArgs) == 0 {
+			return errors.New("type arguments are required for an instance")
+		}
+
+		g.instances = append(g.instances, typeArgs)
+		return nil
+	}
+}
+
+// WithExisting sets a policy for tests that exist already. They are skipped by default.
+func WithExisting(policy ExistingPolicy) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		g.existing = policy
+		return nil
+	}
+}
+
 // WithPreTest overrides default pretest renderer.
 func WithPreTest(pretest func(r *gogh.GoRenderer[*gogh.Imports])) Option {
 	return func(g *Generator, _ optionRestriction) error {
@@ -58,12 +259,53 @@ func WithPreTest(pretest func(r *gogh.GoRenderer[*gogh.Imports])) Option {
 
 // WithCtxInit overrides default context.Context init rendering.
 func WithCtxInit(ctxinit func(r *gogh.GoRenderer[*gogh.Imports])) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		g.ctxInit = func(r *goRenderer, _ ContextInit) {
+			ctxinit(r)
+		}
+		return nil
+	}
+}
+
+// WithContextInit overrides default context.Context init rendering with the one
+// that is told where the context is initialized and what it is used for.
+func WithContextInit(ctxinit ContextInitRenderer) Option {
 	return func(g *Generator, _ optionRestriction) error {
 		g.ctxInit = ctxinit
 		return nil
 	}
 }
 
+// WithCtxParam sets a renderer of contexts for context.Context parameters, so they can
+// get distinct ones. All of them are given ctx by default.
+func WithCtxParam(ctxparam ContextParamRenderer) Option {
+	return func(g *Generator, _ optionRestriction) error {
+		g.ctxParam = ctxparam
+		return nil
+	}
+}
+
+// WithCtxSetup adds a ctxSetup func(ctx context.Context) context.Context field to test
+// cases to derive the context with values, deadlines or cancellation from.
+func WithCtxSetup(g *Generator, _ optionRestriction) error {
+	g.ctxSetup = true
+	return nil
+}
+
+// WithCtxCancellation adds ctxTimeout time.Duration and cancelBeforeCall bool fields
+// to test cases. The test context gets the timeout if it is set and is cancelled
+// before the call if the flag is set.
+func WithCtxCancellation(g *Generator, _ optionRestriction) error {
+	g.ctxCancel = true
+	return nil
+}
+
+// WithParallel makes tests and their subtests run in parallel.
+func WithParallel(g *Generator, _ optionRestriction) error {
+	g.parallel = true
+	return nil
+}
+
 // LoggingRenderer renders error messages.
 // These variables:
 //
@@ -0,0 +1,230 @@
+package generator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/gogh"
+)
+
+// RenderedFile a file created or changed by the generation.
+type RenderedFile struct {
+	// Path is a path of the file.
+	Path string
+	// Old is a content of the file before the generation, nil for new files.
+	Old []byte
+	// Content is a generated content of the file.
+	Content []byte
+}
+
+// stage a scratch copy of the module root generated code is rendered into
+// in the dry run mode, so nothing is written into the module itself.
+type stage struct {
+	root   string
+	module string
+}
+
+// newStage sets up a scratch module root with the module file and copies of directories
+// files can be rendered into. Copies are taken through overlays.
+func (g *Generator) newStage() (*stage, error) {
+	root, err := os.MkdirTemp("", "ttgen-")
+	if err != nil {
+		return nil, errors.Wrap(err, "create scratch directory")
+	}
+
+	s := &stage{
+		root:   root,
+		module: g.pkg.Module.Dir,
+	}
+	if err := s.copyFile(g, g.pkg.Module.GoMod); err != nil {
+		s.remove()
+		return nil, errors.Wrap(err, "copy module file")
+	}
+
+	for _, dir := range g.outputDirs() {
+		if err := s.copyDir(g, dir); err != nil {
+			s.remove()
+			return nil, errors.Wrapf(err, "copy %s", dir)
+		}
+	}
+
+	return s, nil
+}
+
+// newModule creates a code renderer rooted at the scratch module.
+func (s *stage) newModule() (*gogh.Module[*gogh.Imports], error) {
+	return newModule(gogh.WithRoot[*gogh.Imports](s.root))
+}
+
+// path maps the file of the module to its scratch copy.
+func (s *stage) path(name string) (string, error) {
+	rel, err := filepath.Rel(s.module, name)
+	if err != nil {
+		return "", errors.Wrapf(err, "get %s path relative to the module", name)
+	}
+
+	return filepath.Join(s.root, rel), nil
+}
+
+func (s *stage) copyFile(g *Generator, name string) error {
+	data, err := g.readFile(name)
+	if err != nil {
+		return errors.Wrap(err, "read file")
+	}
+
+	dst, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return errors.Wrap(err, "create directory")
+	}
+
+	if err := os.WriteFile(dst, data, 0600); err != nil {
+		return errors.Wrap(err, "write copy")
+	}
+
+	return nil
+}
+
+// copyDir copies regular files of the directory, a missing directory is not copied.
+func (s *stage) copyDir(g *Generator, dir string) error {
+	names, err := g.dirFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := s.copyFile(g, name); err != nil {
+			return errors.Wrapf(err, "copy %s", filepath.Base(name))
+		}
+	}
+
+	return nil
+}
+
+func (s *stage) remove() {
+	_ = os.RemoveAll(s.root)
+}
+
+// outputDirs returns directories generated files can be put in.
+func (g *Generator) outputDirs() []string {
+	dirs := []string{g.pkgDir()}
+	if g.mockGen != nil && g.mockGen.path != "" {
+		dirs = append(dirs, filepath.Join(g.pkg.Module.Dir, filepath.FromSlash(g.mockGen.path)))
+	}
+
+	return dirs
+}
+
+// outputPath returns a path the file of the module is rendered into.
+func (g *Generator) outputPath(name string) (string, error) {
+	if g.stage == nil {
+		return name, nil
+	}
+
+	return g.stage.path(name)
+}
+
+// renderDry renders generated code into the scratch module and returns files it
+// created or changed compared to their overlays or their content on disk.
+func (g *Generator) renderDry() ([]RenderedFile, error) {
+	if err := g.renderFiles(); err != nil {
+		return nil, err
+	}
+
+	var files []RenderedFile
+	for _, dir := range g.outputDirs() {
+		sdir, err := g.stage.path(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := os.ReadDir(sdir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, errors.Wrapf(err, "read rendered files of %s", dir)
+		}
+
+		for _, e := range entries {
+			if !e.Type().IsRegular() {
+				continue
+			}
+
+			content, err := os.ReadFile(filepath.Join(sdir, e.Name()))
+			if err != nil {
+				return nil, errors.Wrapf(err, "read rendered %s", e.Name())
+			}
+
+			name := filepath.Join(dir, e.Name())
+			old, err := g.readFile(name)
+			switch {
+			case os.IsNotExist(err):
+				old = nil
+			case err != nil:
+				return nil, errors.Wrapf(err, "read original %s", name)
+			case bytes.Equal(old, content):
+				continue
+			}
+
+			files = append(files, RenderedFile{
+				Path:    name,
+				Old:     old,
+				Content: content,
+			})
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	return files, nil
+}
+
+// readFile reads the file taking overlays into account.
+func (g *Generator) readFile(name string) ([]byte, error) {
+	if content, ok := g.overlay[name]; ok {
+		return content, nil
+	}
+
+	return os.ReadFile(name)
+}
+
+// dirFiles returns regular files of the directory along with files only
+// existing as overlays there. A missing directory has none.
+func (g *Generator) dirFiles(dir string) ([]string, error) {
+	seen := map[string]struct{}{}
+	var res []string
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "read directory")
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+
+		name := filepath.Join(dir, e.Name())
+		seen[name] = struct{}{}
+		res = append(res, name)
+	}
+
+	for name := range g.overlay {
+		if _, ok := seen[name]; ok || filepath.Dir(name) != dir {
+			continue
+		}
+
+		res = append(res, name)
+	}
+	sort.Strings(res)
+
+	return res, nil
+}
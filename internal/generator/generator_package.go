@@ -0,0 +1,180 @@
+package generator
+
+import (
+	"go/types"
+	"regexp"
+	"strings"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/message"
+)
+
+// PackageFilter selects functions and methods of a package to generate tests for.
+// Functions are matched by their names and methods by Type.Method.
+type PackageFilter struct {
+	// Include only matches if set.
+	Include *regexp.Regexp
+	// Exclude matches if set.
+	Exclude *regexp.Regexp
+	// ExportedOnly omits non-exported functions and methods.
+	ExportedOnly bool
+}
+
+func (f PackageFilter) match(name string, exported bool) bool {
+	if f.ExportedOnly && !exported {
+		return false
+	}
+
+	if f.Include != nil && !f.Include.MatchString(name) {
+		return false
+	}
+
+	if f.Exclude != nil && f.Exclude.MatchString(name) {
+		return false
+	}
+
+	return true
+}
+
+// GenerateForPackage generates table tests for every function and every method of
+// defined types in the package that pass the filter. Everything is rendered at once.
+// Functions and methods tests generation failed for are reported and skipped, so
+// are generic ones when no instances were given.
+func GenerateForPackage(
+	pkg string,
+	filter PackageFilter,
+	mockLookup MockLookup,
+	msgsRenderer LoggingRenderer,
+	opts ...Option,
+) error {
+	g, err := newGenerator(pkg, mockLookup, msgsRenderer, opts...)
+	if err != nil {
+		return errors.Wrap(err, "init generator")
+	}
+	defer g.cleanup()
+
+	p, err := g.testPackage()
+	if err != nil {
+		return errors.Wrap(err, "set up the package renderer")
+	}
+
+	if g.external {
+		filter.ExportedOnly = true
+	}
+
+	funcs := g.packageFunctions(filter)
+	var failed int
+	for _, f := range funcs {
+		if err := g.ctx.Err(); err != nil {
+			return errors.Wrap(err, "generation interrupted")
+		}
+
+		if err := g.generateFor(p, f); err != nil {
+			message.Warning(errors.Wrapf(err, "%s generate test for %s", g.fset.Position(f.Pos()), funcName(f)))
+			failed++
+		}
+	}
+
+	if err := g.render(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return errors.Newf("failed to generate tests for %d of %d functions and methods", failed, len(funcs))
+	}
+
+	return nil
+}
+
+// packageFunctions collects functions and methods of defined types
+// passing the filter. The main function of the main package is left out.
+func (g *Generator) packageFunctions(filter PackageFilter) []*types.Func {
+	var res []*types.Func
+	scope := g.pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		switch v := scope.Lookup(name).(type) {
+		case *types.Func:
+			if v.Name() == "main" && g.pkg.Name == "main" {
+				// It is not called from tests. init functions are not in the scope.
+				continue
+			}
+
+			if !filter.match(v.Name(), v.Exported()) || !g.instantiable(v) {
+				continue
+			}
+
+			res = append(res, v)
+		case *types.TypeName:
+			if v.IsAlias() || (g.external && !v.Exported()) {
+				continue
+			}
+
+			nd, ok := v.Type().(*types.Named)
+			if !ok || underlyingTypeIs[*types.Interface](nd) {
+				continue
+			}
+
+			for i := 0; i < nd.NumMethods(); i++ {
+				m := nd.Method(i)
+				if !filter.match(funcName(m), m.Exported()) || !g.instantiable(m) {
+					continue
+				}
+
+				res = append(res, m)
+			}
+		}
+	}
+
+	return res
+}
+
+// instantiable checks if tests can be generated for the function. Generic functions
+// and methods of generic types are skipped with a warning if there are no instances
+// or the number of type arguments of some instance does not match.
+func (g *Generator) instantiable(f *types.Func) bool {
+	s := f.Type().(*types.Signature)
+	tparams := s.TypeParams()
+	if tparams.Len() == 0 {
+		tparams = s.RecvTypeParams()
+	}
+	if tparams.Len() == 0 {
+		return true
+	}
+
+	if len(g.instances) == 0 {
+		message.Warningf("%s %s is generic and no instances were given, skipping it", g.fset.Position(f.Pos()), funcName(f))
+		return false
+	}
+
+	for _, inst := range g.instances {
+		if len(inst) != tparams.Len() {
+			message.Warningf(
+				"%s %s needs %d type arguments for %s, instance [%s] has %d, skipping it",
+				g.fset.Position(f.Pos()),
+				funcName(f),
+				tparams.Len(),
+				typeParamsString(tparams),
+				strings.Join(inst, ", "),
+				len(inst),
+			)
+			return false
+		}
+	}
+
+	return true
+}
+
+// funcName returns function name or Type.Method for methods.
+func funcName(f *types.Func) string {
+	s := f.Type().(*types.Signature)
+	if s.Recv() == nil {
+		return f.Name()
+	}
+
+	recv, err := receiverType(s)
+	if err != nil {
+		return f.Name()
+	}
+
+	return recv.Obj().Name() + "." + f.Name()
+}
@@ -20,23 +20,21 @@ func (g *Generator) Package(pkg string) (*types.Package, error) {
 	return g.loadPackage(pkg)
 }
 
+// MockBackend to implement PackageProvider.
+func (g *Generator) MockBackend() MockBackend {
+	return g.backend
+}
+
 func (g *Generator) loadPackage(pkg string) (*types.Package, error) {
-	p, ok := g.pkgs[pkg]
-	if ok {
+	if p, ok := g.pkgs[pkg]; ok {
 		return p.Types, nil
 	}
 
-	res, err := packages.Load(
-		&packages.Config{
-			Mode:    PackageLoadMode,
-			Context: nil,
-			Logf: func(format string, args ...interface{}) {
-				message.Infof(format, args...)
-			},
-			Tests: false,
-		},
-		pkg,
-	)
+	if p, ok := g.imported[pkg]; ok {
+		return p, nil
+	}
+
+	res, err := g.load(pkg)
 	if err != nil {
 		return nil, errors.Wrap(err, "load package info")
 	}
@@ -46,15 +44,61 @@ func (g *Generator) loadPackage(pkg string) (*types.Package, error) {
 			return nil, errors.Wrap(p.Errors[0], "check returned package")
 		}
 
-		g.pkgs[p.PkgPath] = p
+		g.register(p)
 	}
 
-	p, ok = g.pkgs[pkg]
-	if ok {
+	if p, ok := g.pkgs[pkg]; ok {
 		return p.Types, nil
 	}
 
 	return nil, ErrorPackageNotFound{pkgname: pkg}
 }
 
-var _ PackageProvider = new(Generator)
+// packagesConfig returns a configuration to load packages with.
+func (g *Generator) packagesConfig() *packages.Config {
+	mode := PackageLoadMode
+	if g.sourceLoad {
+		mode |= packages.NeedDeps
+	}
+
+	return &packages.Config{
+		Mode:    mode,
+		Context: g.ctx,
+		Logf: func(format string, args ...interface{}) {
+			message.Infof(format, args...)
+		},
+		Tests:   false,
+		Overlay: g.overlay,
+	}
+}
+
+// mockPathProvider provides the package of the mock path instead of the package
+// of the type mocked, so lookups look for mocks there.
+type mockPathProvider struct {
+	*Generator
+	from string
+	to   string
+}
+
+// LocalPackage to implement PackageProvider.
+func (p mockPathProvider) LocalPackage(pkg string) (*types.Package, error) {
+	if pkg == p.from {
+		return p.Generator.LocalPackage(p.to)
+	}
+
+	return p.Generator.LocalPackage(pkg)
+}
+
+// Package to implement PackageProvider.
+func (p mockPathProvider) Package(pkg string) (*types.Package, error) {
+	if pkg == p.from {
+		return p.Generator.Package(p.to)
+	}
+
+	return p.Generator.Package(pkg)
+}
+
+var (
+	_ PackageProvider = new(Generator)
+	_ PackageProvider = mockPathProvider{}
+)
@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+func TestInstantiable(t *testing.T) {
+	pkg := checkSource(t, "example.com/generic", `package generic
+
+func Plain() {}
+
+func Map[K comparable, V any](m map[K]V) []K { return nil }
+
+func Single[T any](v T) T { return v }
+
+type Box[T any] struct{ v T }
+
+func (b Box[T]) Get() T { return b.v }
+`)
+	box := pkg.Scope().Lookup("Box").Type().(*types.Named)
+
+	tests := []struct {
+		name      string
+		f         *types.Func
+		instances [][]string
+		want      bool
+	}{
+		{
+			name: "not generic",
+			f:    pkg.Scope().Lookup("Plain").(*types.Func),
+			want: true,
+		},
+		{
+			name: "generic without instances",
+			f:    pkg.Scope().Lookup("Single").(*types.Func),
+			want: false,
+		},
+		{
+			name:      "matching instances",
+			f:         pkg.Scope().Lookup("Map").(*types.Func),
+			instances: [][]string{{"string", "int"}, {"int", "bool"}},
+			want:      true,
+		},
+		{
+			name:      "instance with other number of type arguments",
+			f:         pkg.Scope().Lookup("Single").(*types.Func),
+			instances: [][]string{{"string", "int"}},
+			want:      false,
+		},
+		{
+			name:      "method of generic type",
+			f:         box.Method(0),
+			instances: [][]string{{"int"}},
+			want:      true,
+		},
+		{
+			name:      "method of generic type with mismatching instance",
+			f:         box.Method(0),
+			instances: [][]string{{"int"}, {"string", "int"}},
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Generator{
+				fset:      token.NewFileSet(),
+				instances: tt.instances,
+			}
+			if got := g.instantiable(tt.f); got != tt.want {
+				t.Errorf("instantiable(%s) = %v, want %v", funcName(tt.f), got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/message"
+)
+
+// warnSharedState warns about package level variables declared in the existing mocker
+// file. The user may have put them there and they are shared between parallel subtests.
+func (g *Generator) warnSharedState(mockerFile string) {
+	name := filepath.Join(g.pkgDir(), mockerFile)
+	src, err := g.readFile(name)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			message.Warning(errors.Wrapf(err, "read mocker file %s", name))
+		}
+		return
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), name, src, parser.SkipObjectResolution)
+	if err != nil {
+		message.Warning(errors.Wrapf(err, "parse mocker file %s", name))
+		return
+	}
+
+	var vars []string
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.VAR {
+			continue
+		}
+
+		for _, spec := range gd.Specs {
+			for _, n := range spec.(*ast.ValueSpec).Names {
+				vars = append(vars, n.Name)
+			}
+		}
+	}
+
+	if len(vars) > 0 {
+		message.Warningf(
+			"%s: package level variables %s are shared between parallel subtests",
+			name,
+			strings.Join(vars, ", "),
+		)
+	}
+}
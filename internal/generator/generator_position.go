@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+
+	"github.com/sirkon/errors"
+)
+
+// lookupPosition looks for a function or a method whose declaration, including
+// its doc comment, encloses the given position.
+func (g *Generator) lookupPosition(file string, line, column int) (*types.Func, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "get absolute path of the file")
+	}
+
+	for _, f := range g.pkg.Syntax {
+		if g.fset.File(f.Pos()).Name() != abs {
+			continue
+		}
+
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+
+			start := fd.Pos()
+			if fd.Doc != nil {
+				start = fd.Doc.Pos()
+			}
+			if !encloses(g.fset.Position(start), g.fset.Position(fd.End()), line, column) {
+				continue
+			}
+
+			if fd.Recv == nil {
+				return g.lookupFunction(fd.Name.Name)
+			}
+
+			typ, err := recvTypeName(fd.Recv.List[0].Type)
+			if err != nil {
+				return nil, err
+			}
+
+			return g.lookupMethod(typ, fd.Name.Name)
+		}
+
+		return nil, errors.Newf("no function or method declared at %s:%d", file, line)
+	}
+
+	return nil, errors.Newf("file %s does not belong to the package %s", file, g.path)
+}
+
+// encloses checks if the line and column are within the range.
+func encloses(start, end token.Position, line, column int) bool {
+	if line < start.Line || line > end.Line {
+		return false
+	}
+
+	if column == 0 {
+		return true
+	}
+
+	if line == start.Line && column < start.Column {
+		return false
+	}
+
+	if line == end.Line && column > end.Column {
+		return false
+	}
+
+	return true
+}
+
+// recvTypeName extracts type name from the receiver type expression, like *T or T[K, V].
+func recvTypeName(expr ast.Expr) (string, error) {
+	for {
+		switch v := expr.(type) {
+		case *ast.Ident:
+			return v.Name, nil
+		case *ast.StarExpr:
+			expr = v.X
+		case *ast.ParenExpr:
+			expr = v.X
+		case *ast.IndexExpr:
+			expr = v.X
+		case *ast.IndexListExpr:
+			expr = v.X
+		default:
+			return "", errors.Newf("unexpected receiver type expression %T", expr)
+		}
+	}
+}
@@ -8,7 +8,7 @@ import (
 )
 
 func TestNewGenerator(t *testing.T) {
-	if err := GenerateForFunction(".", "newGenerator", nil); err != nil {
+	if err := GenerateForFunction(".", "newGenerator", nil, nil); err != nil {
 		testlog.Error(t, errors.Wrap(err, "create function table test template"))
 	}
 }
@@ -0,0 +1,209 @@
+package generator
+
+import (
+	"go/types"
+	"strings"
+)
+
+// renderTypeConstructor renders the body of the mocker method creating
+// an instance of the receiver type with mocks. It prefers NewType
+// constructor of the package if its parameters take all mocks and
+// it returns no error, a composite literal is the second choice. Leaves it to the user if
+// neither is possible.
+func (g *Generator) renderTypeConstructor(r *goRenderer, t target, mocks []fieldMock, fieldNames []string) {
+	if g.renderConstructorCall(r, t, mocks, fieldNames) {
+		return
+	}
+
+	if g.renderCompositeLiteral(r, t, mocks, fieldNames) {
+		return
+	}
+
+	r.L(`    // User defined. Mocks for fields:`)
+	r.L(`    //`)
+	for i, mock := range mocks {
+		var fpath []string
+		for _, f := range mock.path {
+			fpath = append(fpath, f.Name())
+		}
+		r.L(`    //    $0: m.$1`, strings.Join(fpath, "."), fieldNames[i])
+	}
+}
+
+// renderConstructorCall renders a call of NewType constructor if it is
+// there, returns no error and its parameters take all mocks, each one by
+// exactly one of them.
+func (g *Generator) renderConstructorCall(r *goRenderer, t target, mocks []fieldMock, fieldNames []string) bool {
+	constrName := "New" + t.recv.Obj().Name()
+	constr, ok := g.pkg.Types.Scope().Lookup(constrName).(*types.Func)
+	if !ok {
+		return false
+	}
+
+	s := constr.Type().(*types.Signature)
+	if s.Recv() != nil {
+		return false
+	}
+
+	if s.TypeParams().Len() != len(t.targs) {
+		return false
+	}
+	if len(t.targs) > 0 {
+		inst, err := types.Instantiate(nil, s, t.targs, true)
+		if err != nil {
+			return false
+		}
+
+		s = inst.(*types.Signature)
+	}
+
+	// NewType(…) *Type, a value is OK as well. Constructors returning errors are
+	// not called as the mocker has no test to report them to.
+	var resPtr bool
+	if s.Results().Len() != 1 {
+		return false
+	}
+	res := s.Results().At(0).Type()
+	if p, ok := res.(*types.Pointer); ok {
+		res = p.Elem()
+		resPtr = true
+	}
+	if !types.Identical(res, t.recv) {
+		return false
+	}
+
+	// Every parameter must have its own mock and every mock must be passed, otherwise
+	// there is no telling where mocks go. The variadic tail can be omitted.
+	used := make([]bool, len(mocks))
+	args := &strings.Builder{}
+	for i := 0; i < s.Params().Len(); i++ {
+		p := s.Params().At(i)
+		if s.Variadic() && i == s.Params().Len()-1 {
+			break
+		}
+
+		found := -1
+		for j, mock := range mocks {
+			if !types.Identical(p.Type(), mock.path[len(mock.path)-1].Type()) {
+				continue
+			}
+
+			if found >= 0 {
+				// Mocks of the same type cannot be told apart.
+				return false
+			}
+			found = j
+		}
+
+		if found < 0 || used[found] {
+			return false
+		}
+
+		used[found] = true
+		if args.Len() > 0 {
+			args.WriteString(", ")
+		}
+		args.WriteString("m." + fieldNames[found])
+	}
+	for _, u := range used {
+		if !u {
+			return false
+		}
+	}
+
+	var call string
+	if len(t.targs) > 0 {
+		var targs []string
+		for _, targ := range t.targs {
+			targs = append(targs, r.Type(targ))
+		}
+		call = constrName + "[" + strings.Join(targs, ", ") + "](" + args.String() + ")"
+	} else {
+		call = constrName + "(" + args.String() + ")"
+	}
+
+	var ret string
+	switch {
+	case resPtr == t.ptrRecv:
+		ret = "x"
+	case resPtr:
+		ret = "*x"
+	default:
+		ret = "&x"
+	}
+
+	if ret == "x" {
+		r.L(`    return $0`, call)
+		return true
+	}
+
+	r.L(`    x := $0`, call)
+	r.L(`    return $0`, ret)
+	return true
+}
+
+// renderCompositeLiteral renders a composite literal of the receiver type with mocks
+// for fields. It is only possible if all mocked fields are accessible from the
+// package of the mocker.
+func (g *Generator) renderCompositeLiteral(r *goRenderer, t target, mocks []fieldMock, fieldNames []string) bool {
+	root := &literalNode{}
+	for i, mock := range mocks {
+		node := root
+		for _, f := range mock.path {
+			if !f.Exported() && f.Pkg().Path() != g.path {
+				return false
+			}
+
+			node = node.child(f)
+		}
+		node.value = "m." + fieldNames[i]
+	}
+
+	if t.ptrRecv {
+		r.L(`    return &$0{`, r.Type(t.recv))
+	} else {
+		r.L(`    return $0{`, r.Type(t.recv))
+	}
+	root.render(r)
+	r.L(`    }`)
+
+	return true
+}
+
+// literalNode a node of composite literal fields tree.
+type literalNode struct {
+	field    *types.Var
+	value    string
+	children []*literalNode
+}
+
+func (n *literalNode) child(f *types.Var) *literalNode {
+	for _, c := range n.children {
+		if c.field == f {
+			return c
+		}
+	}
+
+	c := &literalNode{field: f}
+	n.children = append(n.children, c)
+	return c
+}
+
+func (n *literalNode) render(r *goRenderer) {
+	for _, c := range n.children {
+		if len(c.children) == 0 {
+			r.L(`        $0: $1,`, c.field.Name(), c.value)
+			continue
+		}
+
+		// An embedded structure.
+		ft := c.field.Type()
+		if p, ok := ft.(*types.Pointer); ok {
+			r.L(`        $0: &$1{`, c.field.Name(), r.Type(p.Elem()))
+		} else {
+			r.L(`        $0: $1{`, c.field.Name(), r.Type(ft))
+		}
+		c.render(r)
+		r.L(`        },`)
+	}
+}
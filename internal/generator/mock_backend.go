@@ -0,0 +1,418 @@
+package generator
+
+import (
+	"go/types"
+	"strings"
+
+	"github.com/sirkon/errors"
+	"golang.org/x/mod/modfile"
+)
+
+// MockBackend describes how mocks of a certain mocking library are
+// created and controlled in tests.
+type MockBackend interface {
+	// Name returns backend name.
+	Name() string
+
+	// Constructor checks the mock type from the package fits the backend and returns
+	// its constructor function. Nil constructor means mocks are created with
+	// composite literals.
+	Constructor(pkg *types.Package, mock *types.Named) (*types.Func, error)
+
+	// ControllerType renders a type of the controller passed into mock constructors.
+	// Empty string means there is no controller.
+	ControllerType(r *goRenderer) string
+
+	// Controller renders controller creation in the test body and returns
+	// an expression referring to it. t *testing.T is available in the scope.
+	// Empty string means there is no controller.
+	Controller(r *goRenderer) string
+
+	// NewMock returns an expression creating the mock with the controller ctrl.
+	NewMock(r *goRenderer, mock MockLookupResult, ctrl string) string
+
+	// WaitHint returns an example of the mocker's end method binding to the last
+	// expected call of a background process.
+	WaitHint() string
+}
+
+// NewGomockBackend creates a backend for [mockgen] and [pamgen] mocks. These are
+// created with NewXXX(*gomock.Controller) *XXX constructors. Both archived
+// github.com/golang/mock and maintained go.uber.org/mock are supported,
+// the one to use is detected by mock constructors found. The one the module
+// requires is used before any mock is found.
+//
+// [mockgen]: https://github.com/golang/mock
+// [pamgen]: https://github.com/sirkon/opgen
+func NewGomockBackend() MockBackend {
+	return &gomockBackend{}
+}
+
+// NewGomockBackendFor creates a backend for gomock mocks with the given
+// gomock package path, e.g. go.uber.org/mock/gomock.
+func NewGomockBackendFor(path string) MockBackend {
+	return &gomockBackend{
+		path:  path,
+		fixed: true,
+	}
+}
+
+// NewMockeryBackend creates a backend for [mockery] mocks based on testify. These
+// are created with NewXXX(t) *XXX constructors.
+//
+// [mockery]: https://github.com/vektra/mockery
+func NewMockeryBackend() MockBackend {
+	return mockeryBackend{}
+}
+
+// NewMoqBackend creates a backend for [moq] mocks. These are plain structures
+// with function fields, no constructor is needed.
+//
+// [moq]: https://github.com/matryer/moq
+func NewMoqBackend() MockBackend {
+	return moqBackend{}
+}
+
+// NewMinimockBackend creates a backend for [minimock] mocks. These are created
+// with NewXXX(minimock.Tester) *XXX constructors.
+//
+// [minimock]: https://github.com/gojuno/minimock
+func NewMinimockBackend() MockBackend {
+	return minimockBackend{}
+}
+
+type gomockBackend struct {
+	path     string
+	fixed    bool
+	required string
+}
+
+// Name to implement MockBackend.
+func (*gomockBackend) Name() string {
+	return "gomock"
+}
+
+// Constructor to implement MockBackend.
+func (b *gomockBackend) Constructor(pkg *types.Package, mock *types.Named) (*types.Func, error) {
+	return lookupConstructor(pkg, mock, func(p *types.Var) error {
+		_, err := b.controllerPath(p)
+		return err
+	})
+}
+
+// controllerPath checks the mock constructor parameter is a gomock controller
+// and returns the path of its package.
+func (b *gomockBackend) controllerPath(p *types.Var) (string, error) {
+	prm, err := castNamedTypeOutOfPointer(p.Type())
+	if err != nil {
+		return "", errors.Wrapf(
+			err,
+			"*gomock.%s type expected for the mock constructor parameter, got %s",
+			gomockController,
+			p.Type().String(),
+		)
+	}
+
+	if prm.Obj().Name() != gomockController || prm.Obj().Pkg() == nil {
+		return "", errors.Newf(
+			"*gomock.%s type expected for the mock constructor parameter, got %s",
+			gomockController,
+			p.Type().String(),
+		)
+	}
+
+	path := prm.Obj().Pkg().Path()
+	switch {
+	case b.fixed && path != b.path:
+		return "", errors.Newf(
+			"*%s.%s type expected for the mock constructor parameter, got %s",
+			b.path,
+			gomockController,
+			p.Type().String(),
+		)
+	case !b.fixed && path != gomockPath && path != uberGomockPath:
+		return "", errors.Newf(
+			"*gomock.%s type expected for the mock constructor parameter, got %s",
+			gomockController,
+			p.Type().String(),
+		)
+	}
+
+	return path, nil
+}
+
+// detect takes the gomock package to use from the controller parameter of the constructor
+// of the mock found. Mocks of both gomock packages cannot be used together.
+func (b *gomockBackend) detect(constr *types.Func) error {
+	s, ok := constr.Type().(*types.Signature)
+	if !ok || s.Params().Len() != 1 {
+		return errors.Newf("mock constructor %s with a single controller parameter expected", constr.Name())
+	}
+
+	path, err := b.controllerPath(s.Params().At(0))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case b.path == "":
+		b.path = path
+	case b.path != path:
+		return ErrorMockConflict{
+			used:  b.path,
+			found: path,
+		}
+	}
+
+	return nil
+}
+
+// ControllerType to implement MockBackend.
+func (b *gomockBackend) ControllerType(r *goRenderer) string {
+	r.Imports().Add(b.gomockPath()).Ref("gomock")
+	return r.S(`*$gomock.Controller`)
+}
+
+// Controller to implement MockBackend.
+func (b *gomockBackend) Controller(r *goRenderer) string {
+	r.Imports().Add(b.gomockPath()).Ref("gomock")
+	r.L(`ctrl := $gomock.NewController(t)`)
+	return "ctrl"
+}
+
+// NewMock to implement MockBackend.
+func (*gomockBackend) NewMock(r *goRenderer, mock MockLookupResult, ctrl string) string {
+	return constructorRef(r, mock) + "(" + ctrl + ")"
+}
+
+// WaitHint to implement MockBackend.
+func (*gomockBackend) WaitHint() string {
+	return "m.Mock.EXPECT().….Do(m.end)"
+}
+
+// gomockPath returns gomock package path detected. It is the one of the gomock
+// module required by the module if nothing was found yet, the archived one if
+// there is no telling.
+func (b *gomockBackend) gomockPath() string {
+	switch {
+	case b.path != "":
+		return b.path
+	case b.required != "":
+		return b.required
+	default:
+		return gomockPath
+	}
+}
+
+// requiredGomock returns the gomock package of the only gomock module the module
+// file requires. Returns an empty string if it requires none or both of them.
+func requiredGomock(name string, data []byte) (string, error) {
+	f, err := modfile.ParseLax(name, data, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "parse module file")
+	}
+
+	var res string
+	for _, req := range f.Require {
+		var path string
+		switch req.Mod.Path {
+		case gomockModule:
+			path = gomockPath
+		case uberGomockModule:
+			path = uberGomockPath
+		default:
+			continue
+		}
+
+		if res != "" && res != path {
+			return "", nil
+		}
+		res = path
+	}
+
+	return res, nil
+}
+
+type mockeryBackend struct{}
+
+// Name to implement MockBackend.
+func (mockeryBackend) Name() string {
+	return "mockery"
+}
+
+// Constructor to implement MockBackend.
+func (mockeryBackend) Constructor(pkg *types.Package, mock *types.Named) (*types.Func, error) {
+	return lookupConstructor(pkg, mock, func(p *types.Var) error {
+		if _, ok := p.Type().Underlying().(*types.Interface); !ok {
+			return errors.Newf("test handle interface expected for the mock constructor parameter, got %s", p.Type())
+		}
+
+		return nil
+	})
+}
+
+// ControllerType to implement MockBackend.
+func (mockeryBackend) ControllerType(r *goRenderer) string {
+	r.Imports().Add("testing").Ref("tst")
+	return r.S(`*$tst.T`)
+}
+
+// Controller to implement MockBackend.
+func (mockeryBackend) Controller(r *goRenderer) string {
+	return "t"
+}
+
+// NewMock to implement MockBackend.
+func (mockeryBackend) NewMock(r *goRenderer, mock MockLookupResult, ctrl string) string {
+	return constructorRef(r, mock) + "(" + ctrl + ")"
+}
+
+// WaitHint to implement MockBackend.
+func (mockeryBackend) WaitHint() string {
+	return "m.Mock.On(…).Run(func(mock.Arguments) { m.end() })"
+}
+
+type moqBackend struct{}
+
+// Name to implement MockBackend.
+func (moqBackend) Name() string {
+	return "moq"
+}
+
+// Constructor to implement MockBackend.
+func (moqBackend) Constructor(pkg *types.Package, mock *types.Named) (*types.Func, error) {
+	return nil, nil
+}
+
+// ControllerType to implement MockBackend.
+func (moqBackend) ControllerType(r *goRenderer) string {
+	return ""
+}
+
+// Controller to implement MockBackend.
+func (moqBackend) Controller(r *goRenderer) string {
+	return ""
+}
+
+// NewMock to implement MockBackend.
+func (moqBackend) NewMock(r *goRenderer, mock MockLookupResult, ctrl string) string {
+	return "&" + r.Type(mock.Named) + "{}"
+}
+
+// WaitHint to implement MockBackend.
+func (moqBackend) WaitHint() string {
+	return "m.Mock.MethodFunc = func(…) { defer m.end(); … }"
+}
+
+type minimockBackend struct{}
+
+// Name to implement MockBackend.
+func (minimockBackend) Name() string {
+	return "minimock"
+}
+
+// Constructor to implement MockBackend.
+func (minimockBackend) Constructor(pkg *types.Package, mock *types.Named) (*types.Func, error) {
+	return lookupConstructor(pkg, mock, func(p *types.Var) error {
+		prm, ok := p.Type().(*types.Named)
+		if !ok || prm.Obj().Pkg() == nil || prm.Obj().Pkg().Path() != minimockPath || prm.Obj().Name() != minimockTester {
+			return errors.Newf(
+				"%s.%s type expected for the mock constructor parameter, got %s",
+				minimockPath,
+				minimockTester,
+				p.Type().String(),
+			)
+		}
+
+		return nil
+	})
+}
+
+// ControllerType to implement MockBackend.
+func (minimockBackend) ControllerType(r *goRenderer) string {
+	r.Imports().Add(minimockPath).Ref("minimock")
+	return r.S(`$minimock.Tester`)
+}
+
+// Controller to implement MockBackend.
+func (minimockBackend) Controller(r *goRenderer) string {
+	r.Imports().Add(minimockPath).Ref("minimock")
+	r.L(`ctrl := $minimock.NewController(t)`)
+	return "ctrl"
+}
+
+// NewMock to implement MockBackend.
+func (minimockBackend) NewMock(r *goRenderer, mock MockLookupResult, ctrl string) string {
+	return constructorRef(r, mock) + "(" + ctrl + ")"
+}
+
+// WaitHint to implement MockBackend.
+func (minimockBackend) WaitHint() string {
+	return "m.Mock.MethodMock.Set(func(…) { defer m.end(); … })"
+}
+
+// lookupConstructor looks for NewXXX(<controller>) *XXX function in the package,
+// where XXX is the mock type name. The controller parameter is checked with
+// the given function.
+func lookupConstructor(
+	pkg *types.Package,
+	mock *types.Named,
+	checkController func(p *types.Var) error,
+) (*types.Func, error) {
+	constructor := "New" + mock.Obj().Name()
+	constr := pkg.Scope().Lookup(constructor)
+	if constr == nil {
+		return nil, errors.Newf("type does not a have an expected constructor %s", constructor)
+	}
+
+	// It must be a function.
+	s, ok := constr.Type().(*types.Signature)
+	if !ok {
+		return nil, errors.Newf("%s is not a function", constructor)
+	}
+
+	// It must not be a method at that.
+	if s.Recv() != nil {
+		return nil, errors.Newf("%s must not be a method", constructor)
+	}
+
+	// Must have exactly one argument.
+	if s.Params().Len() != 1 {
+		return nil, errors.Newf("mock constructor must have exactly one argument, has %d", s.Params().Len())
+	}
+
+	// Of the controller type.
+	if err := checkController(s.Params().At(0)); err != nil {
+		return nil, err
+	}
+
+	// Just one return value
+	if s.Results().Len() != 1 {
+		return nil, errors.Newf("mock constructor must have exactly one return value, has %d", s.Results().Len())
+	}
+
+	// Being a pointer to the mock type.
+	prsm, err := castNamedTypeOutOfPointer(s.Results().At(0).Type())
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"*%s type expected for the only result, got %s",
+			mock.Obj().Name(),
+			s.Results().At(0).Type(),
+		)
+	}
+	if prsm.Underlying() != mock.Underlying() {
+		return nil, errors.Newf(
+			"*%s type expected for the only result, got %s",
+			mock.Obj().Name(),
+			s.Results().At(0).Type(),
+		)
+	}
+
+	return constr.(*types.Func), nil
+}
+
+// constructorRef renders a reference to the mock constructor.
+func constructorRef(r *goRenderer, mock MockLookupResult) string {
+	return strings.TrimSuffix(r.Type(mock.Named), mock.Named.Obj().Name()) + mock.Constructor.Name()
+}
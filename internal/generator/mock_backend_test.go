@@ -0,0 +1,263 @@
+package generator
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/testlog"
+)
+
+// Mocks of the Reader interface the way every backend makes them, along with
+// ones having unexpected constructors.
+const (
+	mockeryMocks = `package mocks
+
+type TestingT interface {
+	Errorf(format string, args ...any)
+	Cleanup(func())
+}
+
+type MockReader struct{}
+
+func (*MockReader) Read(p []byte) (int, error) { return 0, nil }
+
+func NewMockReader(t TestingT) *MockReader { return &MockReader{} }
+
+type MockWriter struct{}
+
+func (*MockWriter) Write(p []byte) (int, error) { return 0, nil }
+
+func NewMockWriter(n int) *MockWriter { return &MockWriter{} }
+`
+
+	moqMocks = `package mocks
+
+type ReaderMock struct {
+	ReadFunc func(p []byte) (int, error)
+}
+
+func (m *ReaderMock) Read(p []byte) (int, error) { return m.ReadFunc(p) }
+`
+
+	minimockMocks = `package mocks
+
+import "github.com/gojuno/minimock/v3"
+
+type ReaderMock struct{}
+
+func (*ReaderMock) Read(p []byte) (int, error) { return 0, nil }
+
+func NewReaderMock(t minimock.Tester) *ReaderMock { return &ReaderMock{} }
+
+type WriterMock struct{}
+
+func (*WriterMock) Write(p []byte) (int, error) { return 0, nil }
+
+func NewWriterMock(t interface{ Fatal(args ...any) }) *WriterMock { return &WriterMock{} }
+`
+
+	minimockTesterSource = `package minimock
+
+type Tester interface {
+	Fatal(args ...any)
+}
+`
+)
+
+func TestMockBackends(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend MockBackend
+		src     string
+		mock    string
+		wantErr bool
+		want    string
+	}{
+		{
+			name:    "mockery",
+			backend: NewMockeryBackend(),
+			src:     mockeryMocks,
+			mock:    "MockReader",
+			want:    "mocks.NewMockReader(ctrl)",
+		},
+		{
+			name:    "mockery constructor without test handle",
+			backend: NewMockeryBackend(),
+			src:     mockeryMocks,
+			mock:    "MockWriter",
+			wantErr: true,
+		},
+		{
+			name:    "moq",
+			backend: NewMoqBackend(),
+			src:     moqMocks,
+			mock:    "ReaderMock",
+			want:    "&mocks.ReaderMock{}",
+		},
+		{
+			name:    "minimock",
+			backend: NewMinimockBackend(),
+			src:     minimockMocks,
+			mock:    "ReaderMock",
+			want:    "mocks.NewReaderMock(ctrl)",
+		},
+		{
+			name:    "minimock constructor without tester",
+			backend: NewMinimockBackend(),
+			src:     minimockMocks,
+			mock:    "WriterMock",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := checkSource(t, "example.com/mocks", tt.src)
+			mock := pkg.Scope().Lookup(tt.mock).Type().(*types.Named)
+
+			constr, err := tt.backend.Constructor(pkg, mock)
+			switch {
+			case err != nil && tt.wantErr:
+				testlog.Log(t, errors.Wrap(err, "expected error"))
+				return
+			case err != nil:
+				testlog.Error(t, errors.Wrap(err, "look for mock constructor"))
+				return
+			case tt.wantErr:
+				t.Error("error was expected")
+				return
+			}
+
+			m, err := newModule()
+			if err != nil {
+				testlog.Error(t, errors.Wrap(err, "init code renderer"))
+				return
+			}
+			p, err := m.Package("x", "github.com/sirkon/ttgenlib/internal/generator/testdata/x")
+			if err != nil {
+				testlog.Error(t, errors.Wrap(err, "set up package renderer"))
+				return
+			}
+
+			got := tt.backend.NewMock(p.Go("x_test.go"), MockLookupResult{
+				Named:       mock,
+				Constructor: constr,
+			}, "ctrl")
+			if got != tt.want {
+				t.Errorf("unexpected mock creation %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithMockBackendFresh(t *testing.T) {
+	opt := WithMockBackend(NewGomockBackend())
+
+	var a, b Generator
+	if err := opt(&a, optionRestriction{}); err != nil {
+		testlog.Error(t, errors.Wrap(err, "apply option"))
+		return
+	}
+	if err := opt(&b, optionRestriction{}); err != nil {
+		testlog.Error(t, errors.Wrap(err, "apply option again"))
+		return
+	}
+
+	a.backend.(*gomockBackend).path = uberGomockPath
+	if path := b.backend.(*gomockBackend).gomockPath(); path != gomockPath {
+		t.Errorf("gomock package detected by one generation leaked into another: %s", path)
+	}
+}
+
+// checkSource type checks the package source, github.com/gojuno/minimock/v3 is
+// available to import along with the standard library.
+func checkSource(t *testing.T, path string, src string) *types.Package {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	minimock := parseSource(t, fset, minimockTesterSource)
+	std := importer.Default()
+	cfg := &types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if path == minimockPath {
+				return new(types.Config).Check(path, fset, []*ast.File{minimock}, nil)
+			}
+
+			return std.Import(path)
+		}),
+	}
+
+	pkg, err := cfg.Check(path, fset, []*ast.File{parseSource(t, fset, src)}, nil)
+	if err != nil {
+		testlog.Error(t, errors.Wrap(err, "type check source"))
+		t.FailNow()
+	}
+
+	return pkg
+}
+
+func parseSource(t *testing.T, fset *token.FileSet, src string) *ast.File {
+	t.Helper()
+
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		testlog.Error(t, errors.Wrap(err, "parse source"))
+		t.FailNow()
+	}
+
+	return f
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+func TestRequiredGomock(t *testing.T) {
+	tests := []struct {
+		name string
+		mod  string
+		want string
+	}{
+		{
+			name: "none",
+			mod:  "module x\n\ngo 1.22\n",
+			want: "",
+		},
+		{
+			name: "archived",
+			mod:  "module x\n\nrequire github.com/golang/mock v1.6.0\n",
+			want: gomockPath,
+		},
+		{
+			name: "uber",
+			mod:  "module x\n\nrequire (\n\tgo.uber.org/mock v0.4.0\n\tgithub.com/sirkon/errors v0.5.0\n)\n",
+			want: uberGomockPath,
+		},
+		{
+			name: "both",
+			mod:  "module x\n\nrequire (\n\tgo.uber.org/mock v0.4.0\n\tgithub.com/golang/mock v1.6.0\n)\n",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := requiredGomock("go.mod", []byte(tt.mod))
+			if err != nil {
+				testlog.Error(t, errors.Wrap(err, "look for gomock module"))
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("unexpected gomock package %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -14,40 +14,55 @@ import (
 type PackageProvider interface {
 	LocalPackage(path string) (*types.Package, error)
 	Package(path string) (*types.Package, error)
+	MockBackend() MockBackend
 }
 
 // MockLookupResult a result of mock lookup.
 type MockLookupResult struct {
-	Name        string
-	Named       *types.Named
-	Type        *types.Struct
+	Name  string
+	Named *types.Named
+	Type  *types.Struct
+
+	// Constructor of the mock, can be nil if the mock backend
+	// does not need one.
 	Constructor *types.Func
 }
 
+// packagesPreloader is implemented by package providers able to load several packages
+// in one go.
+type packagesPreloader interface {
+	preloadPackages(paths []string)
+}
+
 // MockLookup is a definition of mock lookup function provided by the user.
 type MockLookup func(p PackageProvider, t *types.Named) (MockLookupResult, error)
 
 // StdMockLookup is a lookup function that should work for
 // Google's [mockgen] and [pamgen] mock generators.
-//  - altPaths is a list of package paths to look in if no mock was found
-//    in object's own package.
-//  - template is a template for mock type name based on the type name. Will
-//    look be "Mock${type}" for mockgen and "${type|P}Mock" for pamgen. P is the
-//    formatting option to translate original type name into the public one,
-//    `pamgen` always translates mock names into public form.
-//  - custom map can specify mock type names for certain types.
+//   - altPaths is a list of package paths to look in if no mock was found
+//     in object's own package.
+//   - template is a template for mock type name based on the type name. Will
+//     look be "Mock${type}" for mockgen and "${type|P}Mock" for pamgen. P is the
+//     formatting option to translate original type name into the public one,
+//     `pamgen` always translates mock names into public form.
+//   - custom map can specify mock type names for certain types.
 //
 // It looks for a mock type in the given type's package first, then move to
-// altPaths provided if no match was found. These criteria must be satisfied:
+// altPaths provided if no match was found. Packages of altPaths are loaded in
+// one go on the first lookup. These criteria must be satisfied:
 //   - The mock type name must be equal to template with type name applied to it.
 //   - The mock type must implement the given type (it is an interface).
-//   - There should be a function NewXXX(*gomock.Controller) *XXX in the package,
-//     where XXX is a mock type name.
+//   - There should be a constructor expected by the mock backend, it is
+//     NewXXX(*gomock.Controller) *XXX by default, where XXX is a mock type name.
 //
 // [mockgen]: https://github.com/golang/mock
 // [pamgen]: https://github.com/sirkon/opgen
 func StdMockLookup(altPaths []string, template string, custom map[string]string) MockLookup {
 	return func(p PackageProvider, t *types.Named) (res MockLookupResult, _ error) {
+		if pl, ok := p.(packagesPreloader); ok {
+			// Load alternative paths at once rather than one by one.
+			pl.preloadPackages(altPaths)
+		}
 
 		var pkgs []*types.Package
 		for i := 0; i < len(altPaths)+1; i++ {
@@ -77,16 +92,10 @@ func StdMockLookup(altPaths []string, template string, custom map[string]string)
 		if v, ok := custom[t.Obj().String()]; ok {
 			mockName = v
 		} else {
-			mockName = format.Formatm(template, format.Values{
-				"type": casesFormatter{
-					value: t.Obj().Name(),
-				},
-			})
+			mockName = formatMockName(template, t)
 		}
-		constructorName := "New" + mockName
-
 		for _, pkg := range pkgs {
-			res, err := mockLookup(pkg, t, mockName, constructorName)
+			res, err := mockLookup(pkg, t, mockName, p.MockBackend())
 			if err == nil {
 				message.Debugf("found a mock %s for %s", res.Named, t.String())
 				return res, nil
@@ -95,9 +104,8 @@ func StdMockLookup(altPaths []string, template string, custom map[string]string)
 			message.Warning(
 				errors.Wrapf(
 					err,
-					"look for mock %s with constructor %s in package %s",
+					"look for mock %s in package %s",
 					mockName,
-					constructorName,
 					pkg.Path(),
 				),
 			)
@@ -107,7 +115,7 @@ func StdMockLookup(altPaths []string, template string, custom map[string]string)
 	}
 }
 
-func mockLookup(pkg *types.Package, t *types.Named, mockName, constructor string) (res MockLookupResult, _ error) {
+func mockLookup(pkg *types.Package, t *types.Named, mockName string, backend MockBackend) (res MockLookupResult, _ error) {
 	// Look for mock type.
 	mock := pkg.Scope().Lookup(mockName)
 	if mock == nil {
@@ -126,80 +134,33 @@ func mockLookup(pkg *types.Package, t *types.Named, mockName, constructor string
 		return res, errors.Wrap(err, "get mock structure type")
 	}
 
-	// Look for the constructor function in the package.
-	constr := pkg.Scope().Lookup(constructor)
-	if constr == nil {
-		return res, errors.Newf("type does not a have an expected constructor %a", constructor)
-	}
-
-	// It must be a function.
-	s, ok := constr.Type().(*types.Signature)
-	if !ok {
-		return res, errors.Newf("%s is not a function", constructor)
-	}
-
-	// It must not be a method at that.
-	if s.Recv() != nil {
-		return res, errors.Newf("%s must not be a method", constructor)
-	}
-
-	// Must have exactly one argument.
-	if s.Params().Len() != 1 {
-		return res, errors.Newf("mock constructor must have exactly one argument, has %d", s.Params().Len())
-	}
-
-	// Of type *gomock.Controller
-	prm, err := castNamedTypeOutOfPointer(s.Params().At(0).Type())
-	if err != nil {
-		return res, errors.Wrapf(
-			err,
-			"%s.%s type expected for the mock constructor parameter, got %s",
-			gomockPath,
-			gomockController,
-			s.Params().At(0).Type().String(),
-		)
-	}
-	if prm.Obj().Pkg().Path() != gomockPath || prm.Obj().Name() != gomockController {
-		return res, errors.Wrapf(
-			err,
-			"%s.%s type expected for the mock constructor parameter, got %s",
-			gomockPath,
-			gomockController,
-			s.Params().At(0).Type().String(),
-		)
-	}
-
-	// Just one return value
-	if s.Results().Len() != 1 {
-		return res, errors.Newf("mock constructor must have exactly one return value, has %d", s.Results().Len())
-	}
-
-	// Being a pointer to the mock type.
-	prsm, err := castNamedTypeOutOfPointer(s.Results().At(0).Type())
+	// Look for the constructor function in the package if the backend needs it.
+	constr, err := backend.Constructor(pkg, mock.Type().(*types.Named))
 	if err != nil {
-		return res, errors.Wrapf(
-			err,
-			"*%s type expected for the only result, got %s",
-			mockName,
-			s.Results().At(0).Type(),
-		)
-	}
-	prmUptr := prsm.Underlying()
-	if prmUptr != mockStruct {
-		return res, errors.Newf(
-			"*%s type expected for the only result, got %s",
-			mockName,
-			s.Results().At(0).Type(),
-		)
+		return res, errors.Wrapf(err, "check %s mock constructor", backend.Name())
 	}
 
-	res.Named = prsm
+	res.Named = mock.Type().(*types.Named)
 	res.Type = mockStruct
-	res.Constructor = constr.(*types.Func)
+	res.Constructor = constr
 
 	return res, nil
 }
 
+// formatMockName applies the type name to the mock type name template.
+func formatMockName(template string, t *types.Named) string {
+	return formatTypeName(template, t.Obj().Name())
+}
+
+// formatTypeName applies the type name to the template.
+func formatTypeName(template string, name string) string {
+	return format.Formatm(template, format.Values{
+		"type": casesFormatter{
+			value: name,
+		},
+	})
+}
+
 type casesFormatter struct {
 	format byte
 	value  string
@@ -0,0 +1,255 @@
+package generator
+
+import (
+	"go/types"
+	"strings"
+	"unicode"
+
+	"github.com/sirkon/errors"
+)
+
+// target a function or a method to generate a test for.
+type target struct {
+	obj *types.Func
+	sig *types.Signature
+
+	// recv is a receiver type, nil for functions.
+	recv *types.Named
+	// ptrRecv is true if x in tests must be a pointer to the receiver type.
+	// It is when the type has methods with pointer receivers.
+	ptrRecv bool
+
+	// targs are type arguments the generic function or receiver type
+	// was instantiated with. Empty for non-generic ones.
+	targs []types.Type
+}
+
+// name returns a name of the test function without Test prefix.
+func (t target) name() string {
+	var name string
+	if t.recv != nil {
+		name = t.recv.Obj().Name() + t.obj.Name()
+	} else {
+		name = t.obj.Name()
+	}
+
+	if len(t.targs) == 0 {
+		return name
+	}
+
+	return name + "_" + strings.Join(t.instanceWords(), "_")
+}
+
+// instanceWords returns type arguments in the form suitable to be
+// a part of identifiers.
+func (t target) instanceWords() []string {
+	var res []string
+	for _, targ := range t.targs {
+		name := types.TypeString(targ, func(p *types.Package) string {
+			return p.Name()
+		})
+
+		var word strings.Builder
+		for _, r := range name {
+			if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+				word.WriteRune(r)
+				continue
+			}
+
+			if word.Len() > 0 {
+				res = append(res, word.String())
+				word.Reset()
+			}
+		}
+		if word.Len() > 0 {
+			res = append(res, word.String())
+		}
+	}
+
+	return res
+}
+
+// targets returns a list of targets for the given function or method.
+// Generic ones are instantiated with each set of type arguments given
+// via WithInstance option.
+func (g *Generator) targets(f *types.Func) ([]target, error) {
+	s := f.Type().(*types.Signature)
+
+	var recv *types.Named
+	tparams := s.TypeParams()
+	if s.Recv() != nil {
+		var err error
+		recv, err = receiverType(s)
+		if err != nil {
+			return nil, errors.Wrap(err, "get receiver type")
+		}
+
+		tparams = s.RecvTypeParams()
+	}
+
+	if tparams.Len() == 0 {
+		return []target{
+			{
+				obj:     f,
+				sig:     s,
+				recv:    recv,
+				ptrRecv: recv != nil && hasPointerMethods(recv),
+			},
+		}, nil
+	}
+
+	if len(g.instances) == 0 {
+		return nil, errors.Newf(
+			"%s is generic, type arguments for %s are needed to instantiate it",
+			f.Name(),
+			typeParamsString(tparams),
+		)
+	}
+
+	var res []target
+	for _, inst := range g.instances {
+		targs, err := g.typeArgs(f, inst)
+		if err != nil {
+			return nil, errors.Wrapf(err, "evaluate type arguments [%s]", strings.Join(inst, ", "))
+		}
+
+		if len(targs) != tparams.Len() {
+			return nil, errors.Newf(
+				"%s needs %d type arguments for %s, got %d",
+				f.Name(),
+				tparams.Len(),
+				typeParamsString(tparams),
+				len(targs),
+			)
+		}
+
+		t, err := instantiate(f, recv, targs)
+		if err != nil {
+			return nil, errors.Wrapf(err, "instantiate %s with [%s]", f.Name(), strings.Join(inst, ", "))
+		}
+
+		res = append(res, t)
+	}
+
+	return res, nil
+}
+
+// typeArgs evaluates type arguments in the scope of the file the function is defined in,
+// so imported packages can be referred to.
+func (g *Generator) typeArgs(f *types.Func, inst []string) ([]types.Type, error) {
+	var res []types.Type
+	for _, expr := range inst {
+		tv, err := types.Eval(g.fset, g.pkg.Types, f.Pos(), expr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "evaluate %s", expr)
+		}
+
+		if !tv.IsType() {
+			return nil, errors.Newf("%s is not a type", expr)
+		}
+
+		res = append(res, tv.Type)
+	}
+
+	return res, nil
+}
+
+func instantiate(f *types.Func, recv *types.Named, targs []types.Type) (target, error) {
+	if recv == nil {
+		inst, err := types.Instantiate(nil, f.Type(), targs, true)
+		if err != nil {
+			return target{}, err
+		}
+
+		return target{
+			obj:   f,
+			sig:   inst.(*types.Signature),
+			targs: targs,
+		}, nil
+	}
+
+	inst, err := types.Instantiate(nil, recv.Origin(), targs, true)
+	if err != nil {
+		return target{}, err
+	}
+
+	named := inst.(*types.Named)
+	for i := 0; i < named.NumMethods(); i++ {
+		m := named.Method(i)
+		if m.Name() != f.Name() {
+			continue
+		}
+
+		return target{
+			obj:     f,
+			sig:     m.Type().(*types.Signature),
+			recv:    named,
+			ptrRecv: hasPointerMethods(recv),
+			targs:   targs,
+		}, nil
+	}
+
+	return target{}, errors.Newf("method %s not found in the instantiated type", f.Name())
+}
+
+// recvType renders the type of x in tests.
+func (t target) recvType(typ func(types.Type) string) string {
+	if t.ptrRecv {
+		return "*" + typ(t.recv)
+	}
+
+	return typ(t.recv)
+}
+
+// receiverType returns a named type of the method receiver.
+func receiverType(s *types.Signature) (*types.Named, error) {
+	t := types.Unalias(s.Recv().Type())
+	if p, ok := t.(*types.Pointer); ok {
+		t = types.Unalias(p.Elem())
+	}
+
+	n, ok := t.(*types.Named)
+	if !ok {
+		return nil, errors.Newf("named receiver type expected, got %s", s.Recv().Type())
+	}
+
+	return n, nil
+}
+
+// hasPointerMethods checks if the type has methods with pointer receivers.
+func hasPointerMethods(n *types.Named) bool {
+	n = n.Origin()
+	for i := 0; i < n.NumMethods(); i++ {
+		s := n.Method(i).Type().(*types.Signature)
+		if _, ok := types.Unalias(s.Recv().Type()).(*types.Pointer); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// funcRef renders a reference to the target function suitable for a call. Type arguments
+// are always given explicitly as there may be not enough parameters to infer them.
+func (t target) funcRef(typ func(types.Type) string) string {
+	if t.recv != nil || len(t.targs) == 0 {
+		return t.obj.Name()
+	}
+
+	var targs []string
+	for _, targ := range t.targs {
+		targs = append(targs, typ(targ))
+	}
+
+	return t.obj.Name() + "[" + strings.Join(targs, ", ") + "]"
+}
+
+func typeParamsString(tparams *types.TypeParamList) string {
+	var names []string
+	for i := 0; i < tparams.Len(); i++ {
+		tp := tparams.At(i)
+		names = append(names, tp.Obj().Name()+" "+tp.Constraint().String())
+	}
+
+	return "[" + strings.Join(names, ", ") + "]"
+}
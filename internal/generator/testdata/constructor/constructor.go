@@ -0,0 +1,78 @@
+package constructor
+
+// Reader reads data.
+type Reader interface {
+	Read(p []byte) (int, error)
+}
+
+// Writer writes data.
+type Writer interface {
+	Write(p []byte) (int, error)
+}
+
+// Full has a constructor taking all of its dependencies.
+type Full struct {
+	w Writer
+}
+
+// NewFull creates Full.
+func NewFull(w Writer) *Full {
+	return &Full{w: w}
+}
+
+// Write writes data.
+func (f *Full) Write(p []byte) (int, error) {
+	return f.w.Write(p)
+}
+
+// Zero has a constructor without parameters.
+type Zero struct {
+	w Writer
+}
+
+// NewZero creates Zero with nothing to write into.
+func NewZero() *Zero {
+	return &Zero{}
+}
+
+// Write writes data.
+func (z *Zero) Write(p []byte) (int, error) {
+	return z.w.Write(p)
+}
+
+// Partial has a constructor taking a part of its dependencies.
+type Partial struct {
+	r Reader
+	w Writer
+}
+
+// NewPartial creates Partial with nothing to write into.
+func NewPartial(r Reader) *Partial {
+	return &Partial{r: r}
+}
+
+// Copy copies a chunk of data.
+func (p *Partial) Copy() (int, error) {
+	buf := make([]byte, 512)
+	n, err := p.r.Read(buf)
+	if err != nil {
+		return 0, err
+	}
+
+	return p.w.Write(buf[:n])
+}
+
+// Checked has a constructor returning an error.
+type Checked struct {
+	w Writer
+}
+
+// NewChecked creates Checked.
+func NewChecked(w Writer) (*Checked, error) {
+	return &Checked{w: w}, nil
+}
+
+// Write writes data.
+func (c *Checked) Write(p []byte) (int, error) {
+	return c.w.Write(p)
+}
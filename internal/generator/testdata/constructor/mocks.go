@@ -0,0 +1,33 @@
+package constructor
+
+import "github.com/golang/mock/gomock"
+
+// MockReader a mock of Reader.
+type MockReader struct {
+	ctrl *gomock.Controller
+}
+
+// NewMockReader creates a mock of Reader.
+func NewMockReader(ctrl *gomock.Controller) *MockReader {
+	return &MockReader{ctrl: ctrl}
+}
+
+// Read to implement Reader.
+func (m *MockReader) Read(p []byte) (int, error) {
+	return 0, nil
+}
+
+// MockWriter a mock of Writer.
+type MockWriter struct {
+	ctrl *gomock.Controller
+}
+
+// NewMockWriter creates a mock of Writer.
+func NewMockWriter(ctrl *gomock.Controller) *MockWriter {
+	return &MockWriter{ctrl: ctrl}
+}
+
+// Write to implement Writer.
+func (m *MockWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
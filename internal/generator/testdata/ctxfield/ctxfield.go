@@ -0,0 +1,18 @@
+package ctxfield
+
+import "context"
+
+// Worker keeps the context it works in.
+type Worker struct {
+	ctx  context.Context
+	name string
+}
+
+// Name returns the name unless the context is done.
+func (w Worker) Name() (string, error) {
+	if err := w.ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return w.name, nil
+}
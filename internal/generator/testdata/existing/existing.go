@@ -0,0 +1,8 @@
+package existing
+
+// Reset empties values.
+func Reset(values []string) {
+	for i := range values {
+		values[i] = ""
+	}
+}
@@ -0,0 +1,27 @@
+package external
+
+// Point a point on a plane.
+type Point struct {
+	X int
+	Y int
+}
+
+// Shift shifts the point by the given offset.
+func Shift(p Point, dx, dy int) Point {
+	return Point{
+		X: p.X + dx,
+		Y: p.Y + dy,
+	}
+}
+
+// Box a set of points.
+type Box struct {
+	points []Point
+}
+
+// Add adds the point into the box and returns how many points are there.
+func (b *Box) Add(p Point) int {
+	b.points = append(b.points, p)
+
+	return len(b.points)
+}
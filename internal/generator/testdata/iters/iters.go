@@ -0,0 +1,14 @@
+package iters
+
+import "iter"
+
+// Groups yields groups of names along with their sizes.
+func Groups(groups map[string][]string) iter.Seq2[[]string, int] {
+	return func(yield func([]string, int) bool) {
+		for _, names := range groups {
+			if !yield(names, len(names)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package missingfield
+
+import "io"
+
+// Counter counts bytes written into the writer.
+type Counter struct {
+	w     io.Writer
+	count int
+}
+
+// Write writes data and counts it.
+func (c *Counter) Write(data []byte) (int, error) {
+	n, err := c.w.Write(data)
+	c.count += n
+
+	return n, err
+}
@@ -0,0 +1,30 @@
+package mockgen
+
+import "io"
+
+// Store keeps values by keys.
+type Store interface {
+	Get(key string) (string, error)
+	Put(key string, values ...[]byte) error
+}
+
+// Cache caches values of the store.
+type Cache struct {
+	store Store
+}
+
+// Get returns the value by the key.
+func (c *Cache) Get(key string) (string, error) {
+	return c.store.Get(key)
+}
+
+// Dump writes values.
+func Dump(w io.Writer, values []string) error {
+	for _, v := range values {
+		if _, err := io.WriteString(w, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
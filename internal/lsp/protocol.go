@@ -0,0 +1,140 @@
+package lsp
+
+import (
+	"encoding/json"
+)
+
+// Only the subset of the protocol needed for code actions is described here.
+
+type request struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+type initializeParams struct {
+	Capabilities struct {
+		TextDocument struct {
+			CodeAction struct {
+				ResolveSupport *struct {
+					Properties []string `json:"properties"`
+				} `json:"resolveSupport"`
+			} `json:"codeAction"`
+		} `json:"textDocument"`
+	} `json:"capabilities"`
+}
+
+type textDocumentItem struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type rng struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type codeActionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Range        rng                    `json:"range"`
+}
+
+type response struct {
+	ID     *json.RawMessage `json:"id"`
+	Result json.RawMessage  `json:"result"`
+	Error  *responseError   `json:"error"`
+}
+
+type codeAction struct {
+	Title   string         `json:"title"`
+	Kind    string         `json:"kind"`
+	Edit    *workspaceEdit `json:"edit,omitempty"`
+	Command *command       `json:"command,omitempty"`
+	Data    *actionData    `json:"data,omitempty"`
+}
+
+type command struct {
+	Title     string       `json:"title"`
+	Command   string       `json:"command"`
+	Arguments []actionData `json:"arguments"`
+}
+
+type executeCommandParams struct {
+	Command   string       `json:"command"`
+	Arguments []actionData `json:"arguments"`
+}
+
+type applyEditParams struct {
+	Label string         `json:"label"`
+	Edit  *workspaceEdit `json:"edit"`
+}
+
+type applyEditResult struct {
+	Applied       bool   `json:"applied"`
+	FailureReason string `json:"failureReason"`
+}
+
+// actionData is kept in code actions to be resolved or executed later.
+type actionData struct {
+	URI      string   `json:"uri"`
+	Position position `json:"position"`
+}
+
+type workspaceEdit struct {
+	DocumentChanges []any `json:"documentChanges"`
+}
+
+type createFile struct {
+	Kind string `json:"kind"`
+	URI  string `json:"uri"`
+}
+
+type textDocumentEdit struct {
+	TextDocument struct {
+		URI     string `json:"uri"`
+		Version *int   `json:"version"`
+	} `json:"textDocument"`
+	Edits []textEdit `json:"edits"`
+}
+
+type textEdit struct {
+	Range   rng    `json:"range"`
+	NewText string `json:"newText"`
+}
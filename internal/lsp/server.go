@@ -0,0 +1,515 @@
+// Package lsp implements a language server providing table tests generation
+// as a code action on function and method declarations.
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/message"
+	"github.com/sirkon/ttgenlib/internal/generator"
+)
+
+// ActionTitle is a title of the code action.
+const ActionTitle = "Generate table test"
+
+// GenerateCommand is a command the code action runs for clients that cannot resolve
+// code actions. The generated edit is sent to the client to apply then.
+const GenerateCommand = "ttgen.generate"
+
+// Config server configuration.
+type Config struct {
+	// Name is a name of the server reported to clients.
+	Name string
+
+	// Generate generates a test for a function or a method declared at the
+	// position with given files overlays and returns files created or changed.
+	// Lines and columns start from 1.
+	Generate func(
+		ctx context.Context,
+		file string,
+		line, column int,
+		overlay map[string][]byte,
+	) ([]generator.RenderedFile, error)
+
+	// Changed is called when the content of the file is changed.
+	Changed func(file string)
+}
+
+// Server a language server state.
+type Server struct {
+	cfg Config
+
+	out  io.Writer
+	lock sync.Mutex
+
+	overlay map[string][]byte
+	resolve bool
+	lastID  int
+}
+
+// Serve serves the language server protocol over the given streams until
+// the exit notification is received or the input is closed.
+func Serve(ctx context.Context, in io.Reader, out io.Writer, cfg Config) error {
+	s := &Server{
+		cfg:     cfg,
+		out:     out,
+		overlay: map[string][]byte{},
+	}
+
+	r := bufio.NewReader(in)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		data, err := readMessage(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return errors.Wrap(err, "read message")
+		}
+
+		var req request
+		if err := json.Unmarshal(data, &req); err != nil {
+			if err := s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if req.Method == "" {
+			// This is a response to the edit application request.
+			s.handleResponse(data)
+			continue
+		}
+
+		if req.Method == "exit" {
+			return nil
+		}
+
+		res, rerr := s.handle(ctx, &req)
+		if req.ID == nil {
+			// Notifications have no responses.
+			if rerr != nil {
+				message.Warningf("handle %s: %s", req.Method, rerr.Message)
+			}
+			continue
+		}
+
+		if err := s.reply(req.ID, res, rerr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, req *request) (any, *responseError) {
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+
+		if rs := params.Capabilities.TextDocument.CodeAction.ResolveSupport; rs != nil {
+			for _, p := range rs.Properties {
+				if p == "edit" {
+					s.resolve = true
+				}
+			}
+		}
+
+		return map[string]any{
+			"capabilities": map[string]any{
+				// Full documents are sent on changes.
+				"textDocumentSync": 1,
+				"codeActionProvider": map[string]any{
+					"codeActionKinds": []string{"refactor"},
+					"resolveProvider": s.resolve,
+				},
+				"executeCommandProvider": map[string]any{
+					"commands": []string{GenerateCommand},
+				},
+			},
+			"serverInfo": map[string]any{
+				"name": s.cfg.Name,
+			},
+		}, nil
+
+	case "initialized":
+		return nil, nil
+
+	case "shutdown":
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+
+		s.setOverlay(params.TextDocument.URI, []byte(params.TextDocument.Text))
+		return nil, nil
+
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+
+		if len(params.ContentChanges) > 0 {
+			text := params.ContentChanges[len(params.ContentChanges)-1].Text
+			s.setOverlay(params.TextDocument.URI, []byte(text))
+		}
+		return nil, nil
+
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+
+		s.setOverlay(params.TextDocument.URI, nil)
+		return nil, nil
+
+	case "textDocument/codeAction":
+		var params codeActionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+
+		return s.codeActions(params)
+
+	case "codeAction/resolve":
+		var action codeAction
+		if err := json.Unmarshal(req.Params, &action); err != nil {
+			return nil, invalidParams(err)
+		}
+
+		if action.Data == nil {
+			return nil, invalidParams(errors.New("no action data"))
+		}
+
+		edit, err := s.generate(ctx, action.Data.URI, action.Data.Position)
+		if err != nil {
+			return nil, &responseError{Code: codeInternalError, Message: err.Error()}
+		}
+
+		action.Edit = edit
+		action.Data = nil
+		return action, nil
+
+	case "workspace/executeCommand":
+		var params executeCommandParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+
+		if params.Command != GenerateCommand {
+			return nil, invalidParams(errors.Newf("unknown command %s", params.Command))
+		}
+		if len(params.Arguments) != 1 {
+			return nil, invalidParams(errors.Newf("one command argument expected, got %d", len(params.Arguments)))
+		}
+
+		arg := params.Arguments[0]
+		edit, err := s.generate(ctx, arg.URI, arg.Position)
+		if err != nil {
+			return nil, &responseError{Code: codeInternalError, Message: err.Error()}
+		}
+
+		if err := s.request("workspace/applyEdit", applyEditParams{
+			Label: ActionTitle,
+			Edit:  edit,
+		}); err != nil {
+			return nil, &responseError{Code: codeInternalError, Message: err.Error()}
+		}
+		return nil, nil
+
+	default:
+		if strings.HasPrefix(req.Method, "$/") {
+			// Optional notifications and requests may be ignored.
+			return nil, nil
+		}
+
+		return nil, &responseError{Code: codeMethodNotFound, Message: "method " + req.Method + " is not supported"}
+	}
+}
+
+// codeActions offers the generation if the range starts in a function declaration.
+func (s *Server) codeActions(params codeActionParams) (any, *responseError) {
+	res := []codeAction{}
+
+	file, err := uriToPath(params.TextDocument.URI)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+
+	src, ok := s.overlay[file]
+	if !ok {
+		if src, err = os.ReadFile(file); err != nil {
+			return nil, &responseError{Code: codeInternalError, Message: err.Error()}
+		}
+	}
+
+	if strings.HasSuffix(file, "_test.go") || !inFuncDecl(file, src, params.Range.Start) {
+		return res, nil
+	}
+
+	// The generation is not cheap, it is postponed until the action is chosen:
+	// the action is resolved or its command is executed then.
+	data := actionData{
+		URI:      params.TextDocument.URI,
+		Position: params.Range.Start,
+	}
+	action := codeAction{
+		Title: ActionTitle,
+		Kind:  "refactor",
+	}
+	if s.resolve {
+		action.Data = &data
+	} else {
+		action.Command = &command{
+			Title:     ActionTitle,
+			Command:   GenerateCommand,
+			Arguments: []actionData{data},
+		}
+	}
+
+	return append(res, action), nil
+}
+
+// generate generates a test for the function at the position and turns rendered files into edits.
+func (s *Server) generate(ctx context.Context, uri string, pos position) (*workspaceEdit, error) {
+	file, err := uriToPath(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	// Characters are counted in UTF-16 code units by the protocol, they
+	// are only used to find the enclosing declaration, so the difference
+	// with bytes does not matter much.
+	files, err := s.cfg.Generate(ctx, file, pos.Line+1, pos.Character+1, s.overlay)
+	if err != nil {
+		return nil, err
+	}
+
+	edit := &workspaceEdit{
+		DocumentChanges: []any{},
+	}
+	for _, f := range files {
+		furi := pathToURI(f.Path)
+		if f.Old == nil {
+			edit.DocumentChanges = append(edit.DocumentChanges, createFile{
+				Kind: "create",
+				URI:  furi,
+			})
+		}
+
+		var de textDocumentEdit
+		de.TextDocument.URI = furi
+		de.Edits = []textEdit{
+			{
+				Range: rng{
+					End: endPosition(f.Old),
+				},
+				NewText: string(f.Content),
+			},
+		}
+		edit.DocumentChanges = append(edit.DocumentChanges, de)
+	}
+
+	return edit, nil
+}
+
+func (s *Server) setOverlay(uri string, content []byte) {
+	file, err := uriToPath(uri)
+	if err != nil {
+		message.Warning(errors.Wrapf(err, "use document %s", uri))
+		return
+	}
+
+	if content == nil {
+		delete(s.overlay, file)
+	} else {
+		s.overlay[file] = content
+	}
+
+	if s.cfg.Changed != nil {
+		s.cfg.Changed(file)
+	}
+}
+
+// handleResponse reports failures of edit applications the client responded with.
+func (s *Server) handleResponse(data []byte) {
+	var resp response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		message.Warning(errors.Wrap(err, "decode client response"))
+		return
+	}
+
+	if resp.Error != nil {
+		message.Warningf("apply generated edit: %s", resp.Error.Message)
+		return
+	}
+
+	var res applyEditResult
+	if err := json.Unmarshal(resp.Result, &res); err != nil {
+		message.Warning(errors.Wrap(err, "decode edit application result"))
+		return
+	}
+
+	if !res.Applied {
+		message.Warningf("generated edit was not applied: %s", res.FailureReason)
+	}
+}
+
+// request sends a request to the client.
+func (s *Server) request(method string, params any) error {
+	s.lastID++
+	return s.write(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      s.lastID,
+		"method":  method,
+		"params":  params,
+	})
+}
+
+func (s *Server) reply(id *json.RawMessage, result any, rerr *responseError) error {
+	// The result must be there even if it is null, unless there is an error.
+	resp := map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+	}
+	if rerr != nil {
+		resp["error"] = rerr
+	} else {
+		resp["result"] = result
+	}
+
+	return s.write(resp)
+}
+
+func (s *Server) write(msg map[string]any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "encode message")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, err := io.WriteString(s.out, "Content-Length: "+strconv.Itoa(len(data))+"\r\n\r\n"); err != nil {
+		return errors.Wrap(err, "write message header")
+	}
+	if _, err := s.out.Write(data); err != nil {
+		return errors.Wrap(err, "write message")
+	}
+
+	return nil
+}
+
+// readMessage reads a message framed with Content-Length header.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return nil, io.EOF
+		}
+
+		return nil, errors.Wrap(err, "read header")
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse content length")
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, errors.Wrap(err, "read content")
+	}
+
+	return data, nil
+}
+
+// inFuncDecl checks if the position is within a function declaration.
+func inFuncDecl(file string, src []byte, pos position) bool {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil && f == nil {
+		return false
+	}
+
+	for _, decl := range f.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Body == nil {
+			continue
+		}
+
+		start := fd.Pos()
+		if fd.Doc != nil {
+			start = fd.Doc.Pos()
+		}
+		if line := pos.Line + 1; line >= fset.Position(start).Line && line <= fset.Position(fd.End()).Line {
+			return true
+		}
+	}
+
+	return false
+}
+
+// endPosition returns the position of the end of the text.
+func endPosition(text []byte) position {
+	lines := strings.Split(string(text), "\n")
+	last := lines[len(lines)-1]
+
+	return position{
+		Line:      len(lines) - 1,
+		Character: len(utf16.Encode([]rune(last))),
+	}
+}
+
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", errors.Wrap(err, "parse document uri")
+	}
+
+	if u.Scheme != "file" {
+		return "", errors.Newf("unsupported document uri scheme %s", u.Scheme)
+	}
+
+	return u.Path, nil
+}
+
+func pathToURI(path string) string {
+	u := url.URL{
+		Scheme: "file",
+		Path:   path,
+	}
+
+	return u.String()
+}
+
+func invalidParams(err error) *responseError {
+	return &responseError{
+		Code:    codeInvalidParams,
+		Message: err.Error(),
+	}
+}
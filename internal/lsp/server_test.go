@@ -0,0 +1,190 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/sirkon/errors"
+	"github.com/sirkon/testlog"
+	"github.com/sirkon/ttgenlib/internal/generator"
+)
+
+func TestServe(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "x.go")
+	testFile := filepath.Join(dir, "x_test.go")
+	const src = "package x\n\n// F does nothing.\nfunc F() {}\n"
+
+	var in bytes.Buffer
+	write := func(msg map[string]any) {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in.WriteString("Content-Length: " + strconv.Itoa(len(data)) + "\r\n\r\n")
+		in.Write(data)
+	}
+	send := func(id int, method string, params any) {
+		msg := map[string]any{
+			"jsonrpc": "2.0",
+			"method":  method,
+			"params":  params,
+		}
+		if id > 0 {
+			msg["id"] = id
+		}
+		write(msg)
+	}
+
+	uri := pathToURI(file)
+	send(1, "initialize", map[string]any{"capabilities": map[string]any{}})
+	send(0, "initialized", map[string]any{})
+	send(0, "textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": uri, "text": src},
+	})
+	requestActions := func(id, line int) {
+		send(id, "textDocument/codeAction", map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"range": map[string]any{
+				"start": map[string]any{"line": line, "character": 0},
+				"end":   map[string]any{"line": line, "character": 0},
+			},
+		})
+	}
+	requestActions(2, 0)
+	requestActions(3, 2)
+	// The client does not resolve code actions, so it executes the action command.
+	send(4, "workspace/executeCommand", map[string]any{
+		"command": GenerateCommand,
+		"arguments": []any{
+			map[string]any{
+				"uri":      uri,
+				"position": map[string]any{"line": 2, "character": 0},
+			},
+		},
+	})
+	// This is a response to the edit application request.
+	write(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"result":  map[string]any{"applied": true},
+	})
+	send(5, "shutdown", nil)
+	send(0, "exit", nil)
+
+	var changed []string
+	var generated int
+	var out bytes.Buffer
+	err := Serve(context.Background(), &in, &out, Config{
+		Name: "ttgen",
+		Generate: func(
+			ctx context.Context,
+			gotFile string,
+			line, column int,
+			overlay map[string][]byte,
+		) ([]generator.RenderedFile, error) {
+			generated++
+			if gotFile != file || line != 3 || column != 1 {
+				return nil, errors.Newf("unexpected position %s:%d:%d", gotFile, line, column)
+			}
+			if string(overlay[file]) != src {
+				return nil, errors.New("overlay was not passed")
+			}
+
+			return []generator.RenderedFile{
+				{
+					Path:    testFile,
+					Content: []byte("package x\n"),
+				},
+			}, nil
+		},
+		Changed: func(file string) {
+			changed = append(changed, file)
+		},
+	})
+	if err != nil {
+		testlog.Error(t, errors.Wrap(err, "serve"))
+		return
+	}
+
+	if len(changed) != 1 || changed[0] != file {
+		t.Errorf("unexpected changed files %v", changed)
+	}
+
+	if generated != 1 {
+		t.Errorf("the generation was expected once on the command execution, got %d", generated)
+	}
+
+	r := bufio.NewReader(&out)
+	responses := map[int]json.RawMessage{}
+	var edits []applyEditParams
+	for {
+		data, err := readMessage(r)
+		if err != nil {
+			break
+		}
+
+		var resp struct {
+			ID     int             `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+			Result json.RawMessage `json:"result"`
+			Error  *responseError  `json:"error"`
+		}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			testlog.Error(t, errors.Wrap(err, "decode response"))
+			return
+		}
+		if resp.Method != "" {
+			if resp.Method != "workspace/applyEdit" {
+				t.Errorf("unexpected request %s", resp.Method)
+				continue
+			}
+
+			var params applyEditParams
+			if err := json.Unmarshal(resp.Params, &params); err != nil {
+				testlog.Error(t, errors.Wrap(err, "decode edit application request"))
+				return
+			}
+			edits = append(edits, params)
+			continue
+		}
+		if resp.Error != nil {
+			t.Errorf("unexpected error in response %d: %s", resp.ID, resp.Error.Message)
+			continue
+		}
+		responses[resp.ID] = resp.Result
+	}
+
+	if len(responses) != 5 {
+		t.Fatalf("5 responses expected, got %d", len(responses))
+	}
+
+	if got := string(responses[2]); got != "[]" {
+		t.Errorf("no actions expected outside of functions, got %s", got)
+	}
+
+	var actions []codeAction
+	if err := json.Unmarshal(responses[3], &actions); err != nil {
+		testlog.Error(t, errors.Wrap(err, "decode code actions"))
+		return
+	}
+	if len(actions) != 1 || actions[0].Title != ActionTitle || actions[0].Edit != nil || actions[0].Command == nil {
+		t.Fatalf("unexpected code actions %s", responses[3])
+	}
+	if cmd := actions[0].Command; cmd.Command != GenerateCommand || len(cmd.Arguments) != 1 || cmd.Arguments[0].URI != uri {
+		t.Errorf("unexpected action command %s", responses[3])
+	}
+
+	if len(edits) != 1 {
+		t.Fatalf("one edit application request expected, got %d", len(edits))
+	}
+	if changes := edits[0].Edit.DocumentChanges; len(changes) != 2 {
+		t.Errorf("file creation and edit expected, got %v", changes)
+	}
+}
@@ -25,8 +25,8 @@ func (TypeName) Predict(args complete.Args) []string {
 					return false
 				}
 
-				_, ok = v.Type.(*ast.StructType)
-				if !ok {
+				// Interfaces have no methods to test.
+				if _, ok := v.Type.(*ast.InterfaceType); ok {
 					return false
 				}
 
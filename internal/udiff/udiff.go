@@ -0,0 +1,224 @@
+// Package udiff renders unified diffs of text files.
+package udiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is a number of unchanged lines shown around changes.
+const DefaultContext = 3
+
+// Unified renders a unified diff between the old and the new texts with
+// DefaultContext lines of context. Nil old text means the file is new,
+// nil new text means it was removed. Returns an empty string if texts
+// are equal.
+func Unified(oldName, newName string, old, new []byte) string {
+	oldLines := splitLines(string(old))
+	newLines := splitLines(string(new))
+	edits := diff(oldLines, newLines)
+
+	var changed bool
+	for _, e := range edits {
+		if e.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	if old == nil {
+		oldName = "/dev/null"
+	}
+	if new == nil {
+		newName = "/dev/null"
+	}
+
+	var buf strings.Builder
+	buf.WriteString("--- " + oldName + "\n")
+	buf.WriteString("+++ " + newName + "\n")
+	writeHunks(&buf, edits, DefaultContext)
+
+	return buf.String()
+}
+
+// edit a line of the diff: ' ' for unchanged, '-' for removed and '+' for added.
+type edit struct {
+	kind byte
+	text string
+}
+
+// splitLines splits the text into lines keeping line endings.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// diff computes the shortest edit script with the Myers algorithm.
+func diff(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	// v[k+max] is the furthest x reached on the diagonal k. The trace
+	// keeps states before each round for the backtracking.
+	v := make([]int, 2*max+2)
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+max] < v[k+1+max]) {
+				x = v[k+1+max]
+			} else {
+				x = v[k-1+max] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+max] = x
+
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, max)
+			}
+		}
+	}
+
+	panic("unreachable")
+}
+
+func backtrack(a, b []string, trace [][]int, max int) []edit {
+	var res []edit
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[k-1+max] < v[k+1+max]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+max]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			res = append(res, edit{kind: ' ', text: a[x-1]})
+			x--
+			y--
+		}
+
+		if d == 0 {
+			break
+		}
+
+		if x == prevX {
+			res = append(res, edit{kind: '+', text: b[y-1]})
+			y--
+		} else {
+			res = append(res, edit{kind: '-', text: a[x-1]})
+			x--
+		}
+	}
+
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+
+	return res
+}
+
+// writeHunks writes changes grouped into hunks with ctx lines of context around.
+func writeHunks(buf *strings.Builder, edits []edit, ctx int) {
+	// Line numbers of both texts before the edit with the given index.
+	oldPos := make([]int, len(edits)+1)
+	newPos := make([]int, len(edits)+1)
+	for i, e := range edits {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if e.kind != '+' {
+			oldPos[i+1]++
+		}
+		if e.kind != '-' {
+			newPos[i+1]++
+		}
+	}
+
+	i := 0
+	for i < len(edits) {
+		if edits[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Join changes separated with no more than 2*ctx unchanged lines.
+		start := i - ctx
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(edits); {
+			if edits[j].kind != ' ' {
+				j++
+				end = j
+				continue
+			}
+
+			k := j
+			for k < len(edits) && edits[k].kind == ' ' {
+				k++
+			}
+			if k == len(edits) || k-j > 2*ctx {
+				break
+			}
+			j = k
+		}
+		stop := end + ctx
+		if stop > len(edits) {
+			stop = len(edits)
+		}
+
+		fmt.Fprintf(
+			buf,
+			"@@ -%s +%s @@\n",
+			hunkRange(oldPos[start], oldPos[stop]-oldPos[start]),
+			hunkRange(newPos[start], newPos[stop]-newPos[start]),
+		)
+		for _, e := range edits[start:stop] {
+			buf.WriteByte(e.kind)
+			buf.WriteString(e.text)
+			if !strings.HasSuffix(e.text, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		i = stop
+	}
+}
+
+// hunkRange renders a range of lines starting after the line before.
+func hunkRange(before, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	default:
+		return fmt.Sprintf("%d,%d", before+1, count)
+	}
+}
@@ -0,0 +1,57 @@
+package udiff
+
+import (
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	type test struct {
+		name string
+		old  []byte
+		new  []byte
+		want string
+	}
+
+	tests := []test{
+		{
+			name: "equal",
+			old:  []byte("a\nb\n"),
+			new:  []byte("a\nb\n"),
+			want: "",
+		},
+		{
+			name: "new file",
+			old:  nil,
+			new:  []byte("a\nb\n"),
+			want: "--- /dev/null\n+++ b/x.go\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "change in the middle",
+			old:  []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n"),
+			new:  []byte("1\n2\n3\n4\nfive\n6\n7\n8\n9\n"),
+			want: "--- a/x.go\n+++ b/x.go\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "separate hunks",
+			old:  []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"),
+			new:  []byte("0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n"),
+			want: "--- a/x.go\n+++ b/x.go\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -7,4 +8,3 @@\n 7\n 8\n 9\n-10\n",
+		},
+		{
+			name: "no newline at end",
+			old:  []byte("a\nb"),
+			new:  []byte("a\nb\n"),
+			want: "--- a/x.go\n+++ b/x.go\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("a/x.go", "b/x.go", tt.old, tt.new)
+			if got != tt.want {
+				t.Errorf("unexpected diff:\n%s\nexpected:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -8,22 +8,65 @@ type MockLookup = generator.MockLookup
 // MockLookupResult a result to be returned when a mock for a given type was found.
 type MockLookupResult = generator.MockLookupResult
 
+// MockBackend describes how mocks of a certain mocking library are created
+// and controlled in tests. Use it with GenMockBackend option.
+type MockBackend = generator.MockBackend
+
+// GomockBackend is a backend for Google's [mockgen] and [pamgen] mocks. This one is the default.
+// Both github.com/golang/mock and go.uber.org/mock are supported, the one used is detected
+// by mocks found or taken from the module requirements before any is found. Generation fails
+// if mocks for both are met.
+//
+// [mockgen]: https://github.com/golang/mock
+// [pamgen]: https://github.com/sirkon/opgen
+func GomockBackend() MockBackend {
+	return generator.NewGomockBackend()
+}
+
+// GomockBackendFor is a backend for gomock mocks with the given gomock package, e.g.
+// go.uber.org/mock/gomock. Mocks for other gomock packages are not accepted.
+func GomockBackendFor(path string) MockBackend {
+	return generator.NewGomockBackendFor(path)
+}
+
+// MockeryBackend is a backend for [mockery] testify based mocks.
+//
+// [mockery]: https://github.com/vektra/mockery
+func MockeryBackend() MockBackend {
+	return generator.NewMockeryBackend()
+}
+
+// MoqBackend is a backend for [moq] mocks.
+//
+// [moq]: https://github.com/matryer/moq
+func MoqBackend() MockBackend {
+	return generator.NewMoqBackend()
+}
+
+// MinimockBackend is a backend for [minimock] mocks.
+//
+// [minimock]: https://github.com/gojuno/minimock
+func MinimockBackend() MockBackend {
+	return generator.NewMinimockBackend()
+}
+
 // StandardMockLookup this is a mock lookup function that is seemingly sufficient for
 // Google's [mockgen] and [pamgen] mock generators.
-//  - altPaths is a list of package paths to look in if no mock was found
-//    in object's own package.
-//  - template is a template for mock type name based on the type name. Will
-//    look be "Mock${type}" for mockgen and "${type|P}Mock" for pamgen. P is the
-//    formatting option to translate original type name into the public one,
-//    `pamgen` always translates mock names into public form.
-//  - custom map can specify mock type names for certain types.
+//   - altPaths is a list of package paths to look in if no mock was found
+//     in object's own package.
+//   - template is a template for mock type name based on the type name. Will
+//     look be "Mock${type}" for mockgen and "${type|P}Mock" for pamgen. P is the
+//     formatting option to translate original type name into the public one,
+//     `pamgen` always translates mock names into public form.
+//   - custom map can specify mock type names for certain types.
 //
 // It looks for a mock type in the given type's package first, then move to
-// altPaths provided if no match was found. These criteria must be satisfied:
+// altPaths provided if no match was found. Packages of altPaths are loaded in
+// one go on the first lookup. These criteria must be satisfied:
 //   - The mock type name must be equal to template with type name applied to it.
 //   - The mock type must implement the given type (it is an interface).
-//   - There should be a function NewXXX(*gomock.Controller) *XXX in the package,
-//     where XXX is a mock type name.
+//   - There should be a constructor expected by the mock backend, it is
+//     NewXXX(*gomock.Controller) *XXX by default, where XXX is a mock type name.
 //
 // [mockgen]: https://github.com/golang/mock
 // [pamgen]: https://github.com/sirkon/opgen
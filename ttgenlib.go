@@ -49,11 +49,59 @@ func Run(
 	ctx, err := parser.Parse(args)
 	parser.FatalIfErrorf(err)
 
+	// Options given with flags take precedence over the project config.
+	var overrides []GenOption
+	for _, inst := range cli.Instances {
+		overrides = append(overrides, GenInstance(inst...))
+	}
+	switch {
+	case cli.Update:
+		overrides = append(overrides, GenExisting(GenExistingUpdate))
+	case cli.Force:
+		overrides = append(overrides, GenExisting(GenExistingForce))
+	}
+	if cli.MockContext {
+		overrides = append(overrides, GenMockContext())
+	}
+	for _, nm := range cli.NoMock {
+		overrides = append(overrides, GenNoMock(nm.pkg, nm.name))
+	}
+	if cli.MockTemplate != "" {
+		overrides = append(overrides, GenMockLookup(StandardMockLookup(nil, cli.MockTemplate, nil)))
+	}
+	if len(cli.MockPath) > 0 {
+		// Mock names in these follow the template of the lookup in effect, it is
+		// either one of the flag, of the project config or the one Run was given.
+		overrides = append(overrides, GenMockPaths(cli.MockPath...))
+	}
+	if cli.TestFile != "" {
+		overrides = append(overrides, GenTestFile(cli.TestFile))
+	}
+	if cli.External {
+		overrides = append(overrides, GenExternalTests())
+	}
+	if cli.CtxCancel {
+		overrides = append(overrides, GenCtxCancellation())
+	}
+	if cli.Parallel {
+		overrides = append(overrides, GenParallel())
+	}
+	if cli.NoLoadCache {
+		overrides = append(overrides, GenSourceLoad())
+	}
+	switch {
+	case cli.DryRun:
+		overrides = append(overrides, GenDryRun(printFiles))
+	case cli.Diff:
+		overrides = append(overrides, GenDryRun(printDiff))
+	}
+
 	runArgs := &runContext{
-		args:    &cli,
-		lookup:  mockLookup,
-		logging: logging,
-		opts:    genOpts,
+		args:      &cli,
+		lookup:    mockLookup,
+		logging:   logging,
+		opts:      genOpts,
+		overrides: overrides,
 	}
 
 	if err := ctx.Run(runArgs); err != nil {